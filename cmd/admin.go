@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"topup-game/config"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"golang.org/x/term"
+)
+
+// minAdminPasswordLength is stricter than the sign-up minimum
+const minAdminPasswordLength = 8
+
+const adminUsage = `Usage: topup-game admin <command> [flags]
+
+Commands:
+  create          Create an admin account
+  reset-password  Set a new password for an account, e.g. the default admin
+`
+
+func runAdmin(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, adminUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		return runAdminCreate(args[1:])
+	case "reset-password":
+		return runAdminResetPassword(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown admin command %q\n\n%s", args[0], adminUsage)
+		os.Exit(2)
+	}
+	return nil
+}
+
+func runAdminCreate(args []string) error {
+	flags := flag.NewFlagSet("admin create", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the admin (required)")
+	generate := flags.Bool("generate-password", false, "generate a password and print it instead of prompting for one")
+	flags.Parse(args)
+
+	if *email == "" {
+		flags.Usage()
+		return errors.New("-email is required")
+	}
+
+	password, err := adminPassword(*generate)
+	if err != nil {
+		return err
+	}
+
+	userAdminService, err := newUserAdminService()
+	if err != nil {
+		return err
+	}
+
+	user, err := userAdminService.CreateUser(0, *email, password, model.RoleAdmin)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return fmt.Errorf("an account with the email %s already exists", *email)
+		}
+		return fmt.Errorf("failed to create admin: %v", err)
+	}
+
+	fmt.Printf("Created admin %s (id %d)\n", user.Email, user.ID)
+	if *generate {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+func runAdminResetPassword(args []string) error {
+	flags := flag.NewFlagSet("admin reset-password", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the account (required)")
+	generate := flags.Bool("generate-password", false, "generate a password and print it instead of prompting for one")
+	flags.Parse(args)
+
+	if *email == "" {
+		flags.Usage()
+		return errors.New("-email is required")
+	}
+
+	password, err := adminPassword(*generate)
+	if err != nil {
+		return err
+	}
+
+	userAdminService, err := newUserAdminService()
+	if err != nil {
+		return err
+	}
+
+	user, err := userAdminService.GetUserByEmail(*email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return fmt.Errorf("no account has the email %s", *email)
+		}
+		return err
+	}
+
+	if _, err := userAdminService.ResetPassword(0, user.ID, password); err != nil {
+		return fmt.Errorf("failed to reset password: %v", err)
+	}
+
+	fmt.Printf("Reset the password of %s (id %d); all of its sessions were logged out\n", user.Email, user.ID)
+	if *generate {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+func newUserAdminService() (service.UserAdminService, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	deps, err := newDependencies(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return deps.UserAdminService, nil
+}
+
+// adminPassword generates a password or asks for one. On a terminal the
+// password is typed twice without echo; otherwise it is read from the first
+// line of stdin, so it can be piped in.
+func adminPassword(generate bool) (string, error) {
+	if generate {
+		return service.GeneratePassword()
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	password, err := promptPassword(stdin, "Password: ")
+	if err != nil {
+		return "", err
+	}
+
+	if isTerminal(os.Stdin) {
+		confirmation, err := promptPassword(stdin, "Confirm password: ")
+		if err != nil {
+			return "", err
+		}
+		if confirmation != password {
+			return "", errors.New("passwords do not match")
+		}
+	}
+
+	switch {
+	case len(password) < minAdminPasswordLength:
+		return "", fmt.Errorf("the password must be at least %d characters", minAdminPasswordLength)
+	case password == defaultAdminPassword:
+		return "", errors.New("the default password cannot be used")
+	}
+	return password, nil
+}
+
+func promptPassword(stdin *bufio.Reader, prompt string) (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, prompt)
+		password, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %v", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func isTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
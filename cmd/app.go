@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"topup-game/config"
+	"topup-game/internal/mailer"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/router"
+	"topup-game/internal/service"
+)
+
+// newDependencies wires the repositories and services every command is built
+// from
+func newDependencies(cfg *config.Config) (router.Dependencies, error) {
+	// Initialize repositories
+	userRepo := repository.NewUserRepository(cfg.DB)
+	tokenRepo := repository.NewTokenRepository(cfg.DB)
+	userTokenRepo := repository.NewUserTokenRepository(cfg.DB)
+	productRepo := repository.NewProductRepository(cfg.DB)
+	transactionRepo := repository.NewTransactionRepository(cfg.DB)
+	idempotencyRepo := repository.NewIdempotencyRepository(cfg.DB)
+	priceTierRepo := repository.NewPriceTierRepository(cfg.DB)
+	walletRepo := repository.NewWalletRepository(cfg.DB)
+	refundRepo := repository.NewRefundRepository(cfg.DB)
+	applicationRepo := repository.NewResellerApplicationRepository(cfg.DB)
+	auditLogRepo := repository.NewAuditLogRepository(cfg.DB)
+	gameRepo := repository.NewGameRepository(cfg.DB)
+	invoiceRepo := repository.NewInvoiceRepository(cfg.DB)
+	syncRunRepo := repository.NewSyncRunRepository(cfg.DB)
+	unitOfWork := repository.NewUnitOfWork(cfg.DB)
+
+	// Initialize VIP Reseller service
+	vipResellerService := service.NewVIPResellerService(
+		cfg.VIPReseller.BaseURL,
+		cfg.VIPReseller.APIKey,
+		cfg.VIPReseller.UserID,
+		cfg.VIPReseller.WebhookSecret,
+		service.ClientOptions{
+			Timeout:          cfg.VIPReseller.Timeout,
+			MaxRetries:       cfg.VIPReseller.MaxRetries,
+			BreakerThreshold: cfg.VIPReseller.BreakerThreshold,
+			BreakerCooldown:  cfg.VIPReseller.BreakerCooldown,
+		},
+	)
+
+	// Initialize suppliers
+	suppliers := []service.Supplier{service.NewVIPResellerSupplier(vipResellerService)}
+	if cfg.Digiflazz.Username != "" {
+		suppliers = append(suppliers, service.NewDigiflazzSupplier(
+			cfg.Digiflazz.BaseURL,
+			cfg.Digiflazz.Username,
+			cfg.Digiflazz.APIKey,
+			service.ClientOptions{
+				Timeout:          cfg.Digiflazz.Timeout,
+				MaxRetries:       cfg.Digiflazz.MaxRetries,
+				BreakerThreshold: cfg.Digiflazz.BreakerThreshold,
+				BreakerCooldown:  cfg.Digiflazz.BreakerCooldown,
+			},
+		))
+	}
+	supplierRegistry := service.NewSupplierRegistry(suppliers...)
+
+	// Initialize payment gateway
+	paymentGateway, err := service.NewPaymentGateway(
+		cfg.Payment.Provider,
+		cfg.Payment.BaseURL,
+		cfg.Payment.CallbackSecret,
+	)
+	if err != nil {
+		return router.Dependencies{}, fmt.Errorf("failed to initialize payment gateway: %v", err)
+	}
+
+	// Initialize mailer
+	mail, err := newMailer(cfg.Mail)
+	if err != nil {
+		return router.Dependencies{}, fmt.Errorf("failed to initialize mailer: %v", err)
+	}
+
+	// Initialize services
+	userService := service.NewUserService(userRepo, tokenRepo, cfg.JWTSecret, service.TokenConfig{
+		AccessTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTTL: cfg.Auth.RefreshTokenTTL,
+	})
+	markup := service.MarkupPolicy{
+		BasisPoints:          int64(cfg.Pricing.MarkupBasisPoints),
+		Fixed:                model.NewMoney(int64(cfg.Pricing.MarkupFixed), model.DefaultCurrency),
+		RoundTo:              model.NewMoney(int64(cfg.Pricing.RoundTo), model.DefaultCurrency),
+		MinMarginBasisPoints: int64(cfg.Pricing.MinMarginBasisPoints),
+	}
+	productService := service.NewProductService(unitOfWork, productRepo, syncRunRepo, vipResellerService, supplierRegistry, markup)
+	invoiceFormat := service.InvoiceFormat{
+		Prefix:     cfg.Invoice.Prefix,
+		DateLayout: cfg.Invoice.DateLayout,
+		Digits:     cfg.Invoice.Digits,
+		CheckDigit: cfg.Invoice.CheckDigit,
+	}
+	invoices, err := service.NewInvoiceGenerator(invoiceRepo, invoiceFormat)
+	if err != nil {
+		return router.Dependencies{}, fmt.Errorf("invalid invoice format: %v", err)
+	}
+	topUpFormat := invoiceFormat
+	topUpFormat.Prefix = service.TopUpInvoicePrefix
+	topUpInvoices, err := service.NewInvoiceGenerator(invoiceRepo, topUpFormat)
+	if err != nil {
+		return router.Dependencies{}, fmt.Errorf("invalid invoice format: %v", err)
+	}
+	pricingService := service.NewPricingService(priceTierRepo, userRepo, markup)
+	accountValidator, err := service.NewGameAccountValidator(gameRepo, service.DefaultGameAccountRules, vipResellerService)
+	if err != nil {
+		return router.Dependencies{}, fmt.Errorf("failed to initialize game account validator: %v", err)
+	}
+	transactionService := service.NewTransactionService(unitOfWork, transactionRepo, productRepo, supplierRegistry, paymentGateway, pricingService, accountValidator, invoices)
+	walletService := service.NewWalletService(unitOfWork, walletRepo, paymentGateway, topUpInvoices)
+	gameService := service.NewGameService(gameRepo, productRepo)
+	refundService := service.NewRefundService(unitOfWork, transactionRepo, refundRepo, paymentGateway)
+	applicationService := service.NewResellerApplicationService(unitOfWork, userRepo, applicationRepo)
+	userAdminService := service.NewUserAdminService(unitOfWork, userRepo, auditLogRepo, userService)
+	accountService := service.NewAccountService(unitOfWork, userRepo, userTokenRepo, userService, mail, service.AccountConfig{
+		BaseURL:              cfg.Auth.AppBaseURL,
+		PasswordResetTTL:     cfg.Auth.PasswordResetTTL,
+		EmailVerificationTTL: cfg.Auth.EmailVerificationTTL,
+	})
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyTTL)
+
+	return router.Dependencies{
+		UserService:        userService,
+		ProductService:     productService,
+		TransactionService: transactionService,
+		PaymentGateway:     paymentGateway,
+		IdempotencyService: idempotencyService,
+		VIPReseller:        vipResellerService,
+		PricingService:     pricingService,
+		WalletService:      walletService,
+		RefundService:      refundService,
+		AccountValidator:   accountValidator,
+		GameService:        gameService,
+		ApplicationService: applicationService,
+		UserAdminService:   userAdminService,
+		AccountService:     accountService,
+		WebhookAllowedIPs:  cfg.VIPReseller.WebhookAllowedIPs,
+	}, nil
+}
+
+// newMailer creates the mailer selected by the MAIL_DRIVER setting
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})
+	case "log":
+		var w io.Writer = os.Stdout
+		if cfg.LogFile != "" {
+			file, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err != nil {
+				return nil, err
+			}
+			w = file
+		}
+		return mailer.NewLogMailer(w), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
@@ -1,69 +1,50 @@
+// Command topup-game runs the Top Up Game server and its maintenance tasks
 package main
 
 import (
 	"fmt"
 	"log"
-	"topup-game/config"
-	"topup-game/internal/model"
-	"topup-game/internal/repository"
-	"topup-game/internal/router"
-	"topup-game/internal/service"
+	"os"
 )
 
-func main() {
-	// Load configuration
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	// Auto migrate database
-	err = cfg.DB.AutoMigrate(
-		&model.User{},
-		&model.Product{},
-		&model.Transaction{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
-	}
+const usage = `Usage: topup-game <command> [flags]
 
-	// Initialize repositories
-	userRepo := repository.NewUserRepository(cfg.DB)
-	productRepo := repository.NewProductRepository(cfg.DB)
-	transactionRepo := repository.NewTransactionRepository(cfg.DB)
+Commands:
+  serve          Run the HTTP server and background workers (default)
+  migrate        Bring the database schema up to date
+  admin create   Create an admin account
+  admin reset-password
+                 Set a new password for an account
+  sync-products  Sync the product catalog with VIP Reseller
 
-	// Initialize VIP Reseller service
-	vipResellerService := service.NewVIPResellerService(
-		cfg.VIPReseller.BaseURL,
-		cfg.VIPReseller.APIKey,
-		cfg.VIPReseller.UserID,
-	)
+Run "topup-game <command> -h" for the flags of a command.
+`
 
-	// Initialize services
-	userService := service.NewUserService(userRepo, cfg.JWTSecret)
-	productService := service.NewProductService(productRepo, vipResellerService)
-	transactionService := service.NewTransactionService(transactionRepo, productRepo, vipResellerService)
-
-	// Setup router
-	r := router.SetupRouter(userService, productService, transactionService)
-
-	// Create default admin user if not exists
-	createDefaultAdmin(userService)
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
 
-	// Start server
-	port := "8080"
-	fmt.Printf("Server is running on http://localhost:%s\n", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	var err error
+	switch args[0] {
+	case "serve":
+		err = runServe(args[1:])
+	case "migrate":
+		err = runMigrate(args[1:])
+	case "admin":
+		err = runAdmin(args[1:])
+	case "sync-products":
+		err = runSyncProducts(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], usage)
+		os.Exit(2)
 	}
-}
 
-func createDefaultAdmin(userService service.UserService) {
-	_, err := userService.Register("admin@example.com", "admin123", model.RoleAdmin)
 	if err != nil {
-		// If error is not because email is taken, log it
-		if err != repository.ErrEmailTaken {
-			log.Printf("Failed to create default admin: %v", err)
-		}
+		log.Fatalf("%s: %v", args[0], err)
 	}
 }
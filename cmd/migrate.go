@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"topup-game/config"
+	"topup-game/internal/database"
+)
+
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	if err := database.Migrate(cfg.DB); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	log.Println("Database is up to date")
+	return nil
+}
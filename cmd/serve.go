@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"topup-game/config"
+	"topup-game/internal/database"
+	"topup-game/internal/router"
+	"topup-game/internal/service"
+	"topup-game/internal/worker"
+)
+
+// Credentials of the admin account that older versions created on every boot
+const (
+	defaultAdminEmail    = "admin@example.com"
+	defaultAdminPassword = "admin123"
+)
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrate := flags.Bool("migrate", true, "bring the database schema up to date before starting")
+	flags.Parse(args)
+
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	// Migrate database
+	if *migrate {
+		if err := database.Migrate(cfg.DB); err != nil {
+			return fmt.Errorf("failed to migrate database: %v", err)
+		}
+	}
+
+	deps, err := newDependencies(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDefaultAdmin(deps.UserService); err != nil {
+		return err
+	}
+
+	// Setup router
+	r := router.SetupRouter(deps)
+
+	// Only trust X-Forwarded-For from known proxies, so client IPs used by
+	// the webhook allowlist cannot be spoofed
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %v", err)
+	}
+
+	// Stop background workers and the server on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background workers
+	var workers sync.WaitGroup
+	reconciler := worker.NewReconciler(deps.TransactionService, worker.ReconcilerConfig{
+		Interval:    cfg.Reconciler.Interval,
+		BatchSize:   cfg.Reconciler.BatchSize,
+		Concurrency: cfg.Reconciler.Concurrency,
+	})
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		reconciler.Run(ctx)
+	}()
+	if cfg.CatalogSync.Interval > 0 {
+		catalogSyncer := worker.NewCatalogSyncer(deps.ProductService, worker.CatalogSyncConfig{
+			Interval: cfg.CatalogSync.Interval,
+		})
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			catalogSyncer.Run(ctx)
+		}()
+	}
+
+	// Start server
+	port := "8080"
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+	go func() {
+		fmt.Printf("Server is running on http://localhost:%s\n", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server gracefully: %v", err)
+	}
+	workers.Wait()
+	return nil
+}
+
+// checkDefaultAdmin refuses to serve while the admin account older versions
+// created still accepts its well-known password
+func checkDefaultAdmin(userService service.UserService) error {
+	_, err := userService.Authenticate(defaultAdminEmail, defaultAdminPassword)
+	switch {
+	case err == nil, errors.Is(err, service.ErrAccountSuspended):
+		return fmt.Errorf("the admin account %s still has its default password; "+
+			"change it with \"topup-game admin reset-password -email %s\" before starting the server",
+			defaultAdminEmail, defaultAdminEmail)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return nil
+	default:
+		return fmt.Errorf("failed to check for default admin credentials: %v", err)
+	}
+}
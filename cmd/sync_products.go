@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"topup-game/config"
+	"topup-game/internal/service"
+)
+
+func runSyncProducts(args []string) error {
+	flags := flag.NewFlagSet("sync-products", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would change without saving it")
+	verbose := flags.Bool("v", false, "list every created, updated, deactivated or failed product")
+	flags.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+
+	deps, err := newDependencies(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	run, err := deps.ProductService.SyncProductsWithVIPReseller(ctx, service.SyncOptions{DryRun: *dryRun})
+	if run != nil {
+		if run.ID != 0 {
+			fmt.Printf("Sync run %d: %s\n", run.ID, run.Status)
+		} else {
+			fmt.Printf("Dry run: %s\n", run.Status)
+		}
+		fmt.Printf("created %d, updated %d, unchanged %d, deactivated %d, reactivated %d, failed %d\n",
+			run.Created, run.Updated, run.Unchanged, run.Deactivated, run.Reactivated, run.Failed)
+		if *verbose {
+			for _, item := range run.Items {
+				fmt.Printf("  %-11s %s %s %s\n", item.Action, item.SKU, item.Name, item.Reason)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync products: %v", err)
+	}
+	return nil
+}
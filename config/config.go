@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
@@ -12,16 +15,110 @@ import (
 
 // Config holds all configuration for our application
 type Config struct {
-	DB           *gorm.DB
-	JWTSecret    string
-	VIPReseller  VIPResellerConfig
+	DB          *gorm.DB
+	JWTSecret   string
+	Auth        AuthConfig
+	Mail        MailConfig
+	VIPReseller VIPResellerConfig
+	Digiflazz   DigiflazzConfig
+	Payment     PaymentConfig
+	Reconciler  ReconcilerConfig
+	CatalogSync CatalogSyncConfig
+	Pricing     PricingConfig
+	Invoice     InvoiceConfig
+
+	// TrustedProxies are the proxies whose X-Forwarded-For header is believed
+	TrustedProxies []string
+
+	// IdempotencyTTL is how long an Idempotency-Key and its response are kept
+	IdempotencyTTL time.Duration
+}
+
+// AuthConfig holds the lifetimes of issued tokens
+type AuthConfig struct {
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	// AppBaseURL is where links in emails point to
+	AppBaseURL string
+}
+
+// MailConfig holds configuration for outgoing email. The "log" driver writes
+// messages to LogFile, or to stdout when it is empty, instead of sending them.
+type MailConfig struct {
+	Driver       string
+	From         string
+	LogFile      string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
 }
 
 // VIPResellerConfig holds configuration for VIP Reseller API
 type VIPResellerConfig struct {
-	APIKey  string
-	UserID  string
-	BaseURL string
+	APIKey            string
+	UserID            string
+	BaseURL           string
+	WebhookSecret     string
+	WebhookAllowedIPs []string
+	Timeout           time.Duration
+	MaxRetries        int
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+}
+
+// DigiflazzConfig holds configuration for the Digiflazz supplier API. The
+// supplier is only enabled when a username is set.
+type DigiflazzConfig struct {
+	Username         string
+	APIKey           string
+	BaseURL          string
+	Timeout          time.Duration
+	MaxRetries       int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// PaymentConfig holds configuration for the payment gateway
+type PaymentConfig struct {
+	Provider       string
+	BaseURL        string
+	CallbackSecret string
+}
+
+// PricingConfig holds the markup policy applied to synced products. Amounts
+// are in whole rupiah.
+type PricingConfig struct {
+	MarkupBasisPoints    int
+	MarkupFixed          int
+	RoundTo              int
+	MinMarginBasisPoints int
+}
+
+// InvoiceConfig holds the format of invoice numbers, e.g.
+// INV-20240101-0000428 with a check digit at the end
+type InvoiceConfig struct {
+	Prefix string
+	// DateLayout is a Go time layout; the counter restarts for every date.
+	// An empty layout numbers all invoices in one sequence.
+	DateLayout string
+	Digits     int
+	CheckDigit bool
+}
+
+// ReconcilerConfig holds configuration for the background transaction reconciler
+type ReconcilerConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Concurrency int
+}
+
+// CatalogSyncConfig holds configuration for the periodic product catalog sync
+type CatalogSyncConfig struct {
+	// Interval between syncs; zero disables the periodic sync
+	Interval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -42,7 +139,10 @@ func LoadConfig() (*Config, error) {
 		dbHost, dbUser, dbPassword, dbName, dbPort)
 
 	// Initialize database connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Report unique violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
@@ -51,10 +151,140 @@ func LoadConfig() (*Config, error) {
 	return &Config{
 		DB:        db,
 		JWTSecret: os.Getenv("JWT_SECRET"),
+		Auth: AuthConfig{
+			AccessTokenTTL:       getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:      getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			EmailVerificationTTL: getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			AppBaseURL:           getEnvString("APP_BASE_URL", "http://localhost:8080"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnvString("MAIL_DRIVER", "log"),
+			From:         getEnvString("MAIL_FROM", "Top Up Game <no-reply@localhost>"),
+			LogFile:      os.Getenv("MAIL_LOG_FILE"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
 		VIPReseller: VIPResellerConfig{
-			APIKey:  os.Getenv("VIP_RESELLER_API_KEY"),
-			UserID:  os.Getenv("VIP_RESELLER_USER_ID"),
-			BaseURL: os.Getenv("VIP_RESELLER_BASE_URL"),
+			APIKey:            os.Getenv("VIP_RESELLER_API_KEY"),
+			UserID:            os.Getenv("VIP_RESELLER_USER_ID"),
+			BaseURL:           os.Getenv("VIP_RESELLER_BASE_URL"),
+			WebhookSecret:     os.Getenv("VIP_RESELLER_WEBHOOK_SECRET"),
+			WebhookAllowedIPs: getEnvList("VIP_RESELLER_WEBHOOK_IPS"),
+			Timeout:           getEnvDuration("VIP_RESELLER_TIMEOUT", 10*time.Second),
+			MaxRetries:        getEnvInt("VIP_RESELLER_MAX_RETRIES", 2),
+			BreakerThreshold:  getEnvInt("VIP_RESELLER_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:   getEnvDuration("VIP_RESELLER_BREAKER_COOLDOWN", 30*time.Second),
+		},
+		Digiflazz: DigiflazzConfig{
+			Username:         os.Getenv("DIGIFLAZZ_USERNAME"),
+			APIKey:           os.Getenv("DIGIFLAZZ_API_KEY"),
+			BaseURL:          os.Getenv("DIGIFLAZZ_BASE_URL"),
+			Timeout:          getEnvDuration("DIGIFLAZZ_TIMEOUT", 10*time.Second),
+			MaxRetries:       getEnvInt("DIGIFLAZZ_MAX_RETRIES", 2),
+			BreakerThreshold: getEnvInt("DIGIFLAZZ_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getEnvDuration("DIGIFLAZZ_BREAKER_COOLDOWN", 30*time.Second),
+		},
+		Payment: PaymentConfig{
+			Provider:       os.Getenv("PAYMENT_PROVIDER"),
+			BaseURL:        os.Getenv("PAYMENT_BASE_URL"),
+			CallbackSecret: os.Getenv("PAYMENT_CALLBACK_SECRET"),
+		},
+		Reconciler: ReconcilerConfig{
+			Interval:    getEnvDuration("RECONCILE_INTERVAL", time.Minute),
+			BatchSize:   getEnvInt("RECONCILE_BATCH_SIZE", 50),
+			Concurrency: getEnvInt("RECONCILE_CONCURRENCY", 5),
+		},
+		CatalogSync: CatalogSyncConfig{
+			Interval: getEnvDuration("CATALOG_SYNC_INTERVAL", 6*time.Hour),
+		},
+		Pricing: PricingConfig{
+			MarkupBasisPoints:    getEnvInt("PRICING_MARKUP_BPS", 1000),
+			MarkupFixed:          getEnvInt("PRICING_MARKUP_FIXED", 0),
+			RoundTo:              getEnvInt("PRICING_ROUND_TO", 100),
+			MinMarginBasisPoints: getEnvInt("PRICING_MIN_MARGIN_BPS", 300),
+		},
+		Invoice: InvoiceConfig{
+			Prefix:     getEnvString("INVOICE_PREFIX", "INV"),
+			DateLayout: getEnvString("INVOICE_DATE_LAYOUT", "20060102"),
+			Digits:     getEnvInt("INVOICE_DIGITS", 6),
+			CheckDigit: getEnvBool("INVOICE_CHECK_DIGIT", true),
 		},
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
 	}, nil
 }
+
+// getEnvDuration reads a duration such as "30s" or "24h", falling back to
+// defaultValue when the variable is unset or invalid
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s: %v, using %s", key, err, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getEnvInt reads an integer, falling back to defaultValue when the variable
+// is unset or invalid
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %v, using %d", key, err, defaultValue)
+		return defaultValue
+	}
+	return number
+}
+
+// getEnvString reads a string, falling back to defaultValue when the
+// variable is unset. Set it to "-" for an empty value.
+func getEnvString(key, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return defaultValue
+	}
+	if value == "-" {
+		return ""
+	}
+	return value
+}
+
+// getEnvBool reads a boolean such as "true" or "0", falling back to
+// defaultValue when the variable is unset or invalid
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %v, using %t", key, err, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+// getEnvList reads a comma separated list, ignoring empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
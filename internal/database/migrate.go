@@ -0,0 +1,153 @@
+package database
+
+import (
+	"strings"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Migrate brings the database schema up to date and converts data written
+// by older versions of the application
+func Migrate(db *gorm.DB) error {
+	if err := migrateVIPOrderIDs(db); err != nil {
+		return err
+	}
+	if err := migrateMoneyColumns(db); err != nil {
+		return err
+	}
+	hadProductCost := db.Migrator().HasColumn(&model.Product{}, "cost")
+
+	err := db.AutoMigrate(
+		&model.User{},
+		&model.Game{},
+		&model.Product{},
+		&model.ProductSupplier{},
+		&model.Transaction{},
+		&model.TransactionEvent{},
+		&model.IdempotencyKey{},
+		&model.PriceTier{},
+		&model.MarginRule{},
+		&model.Wallet{},
+		&model.LedgerEntry{},
+		&model.WalletTopUp{},
+		&model.Refund{},
+		&model.InvoiceCounter{},
+		&model.SyncRun{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.ResellerApplication{},
+		&model.AuditLog{},
+		&model.UserToken{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateLegacyTransactionStatuses(db); err != nil {
+		return err
+	}
+
+	// Orders placed before suppliers were recorded all went to VIP Reseller
+	err = db.Model(&model.Transaction{}).
+		Where("supplier_order_id <> '' AND (supplier IS NULL OR supplier = '')").
+		Update("supplier", "vip_reseller").Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Model(&model.TransactionEvent{}).
+		Where("source = ?", "vip_sync").
+		Update("source", model.EventSourceSupplierSync).Error
+	if err != nil {
+		return err
+	}
+
+	if hadProductCost {
+		return nil
+	}
+
+	// Until costs were tracked, products were sold at the supplier's price
+	return db.Model(&model.Product{}).
+		Where("cost = 0").
+		Update("cost", gorm.Expr("price")).Error
+}
+
+// migrateVIPOrderIDs renames vip_order_id to supplier_order_id. The old
+// unique index is dropped: it rejected every transaction without an order
+// after the first, and order IDs are only unique per supplier.
+func migrateVIPOrderIDs(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&model.Transaction{}, "vip_order_id") {
+		return nil
+	}
+
+	if err := db.Exec("DROP INDEX IF EXISTS idx_transactions_vip_order_id").Error; err != nil {
+		return err
+	}
+	return migrator.RenameColumn(&model.Transaction{}, "vip_order_id", "supplier_order_id")
+}
+
+// moneyColumns were stored as double precision rupiah before amounts became
+// integer minor units
+var moneyColumns = []struct {
+	table  string
+	column string
+}{
+	{"products", "price"},
+	{"transactions", "amount"},
+}
+
+// migrateMoneyColumns converts floating point rupiah columns to bigint minor
+// units. AutoMigrate would change the type without scaling the values.
+func migrateMoneyColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, money := range moneyColumns {
+		if !migrator.HasColumn(money.table, money.column) {
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(money.table)
+		if err != nil {
+			return err
+		}
+		for _, columnType := range columnTypes {
+			if columnType.Name() != money.column || !isFloatColumn(columnType.DatabaseTypeName()) {
+				continue
+			}
+
+			column := clause.Column{Name: money.column}
+			err := db.Exec("ALTER TABLE ? ALTER COLUMN ? TYPE bigint USING round(? * 100)::bigint",
+				clause.Table{Name: money.table}, column, column).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isFloatColumn(databaseType string) bool {
+	switch strings.ToLower(databaseType) {
+	case "float4", "float8", "real", "double precision", "numeric", "decimal":
+		return true
+	}
+	return false
+}
+
+// migrateLegacyTransactionStatuses maps the old pending status onto the
+// transaction lifecycle: pending rows with a supplier order are being
+// processed, the rest are still waiting for payment
+func migrateLegacyTransactionStatuses(db *gorm.DB) error {
+	err := db.Model(&model.Transaction{}).
+		Where("status = ? AND supplier_order_id <> ''", "pending").
+		Update("status", model.StatusProcessing).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Model(&model.Transaction{}).
+		Where("status = ?", "pending").
+		Update("status", model.StatusAwaitingPayment).Error
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AccountHandler struct {
+	accountService service.AccountService
+	validator      *validator.Validate
+}
+
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		validator:      validator.New(),
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type CompletePasswordResetRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ForgotPassword handles requesting a password reset link. The response is
+// the same whether or not the email belongs to an account.
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	// Failures are only logged: answering differently would tell which
+	// addresses have accounts
+	if err := h.accountService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		log.Printf("Failed to send password reset email: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "If the email belongs to an account, a password reset link has been sent"})
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req CompletePasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserTokenInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset link"})
+		case errors.Is(err, service.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
+
+// VerifyEmail handles confirming an email address. The token is read from
+// the query string, so the emailed link works when opened directly, or from
+// a JSON body.
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	req := VerifyEmailRequest{Token: c.Query("token")}
+	if req.Token == "" && c.Request.Method == http.MethodPost {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	user, err := h.accountService.VerifyEmail(req.Token)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified",
+		"user": gin.H{
+			"id":                user.ID,
+			"email":             user.Email,
+			"email_verified_at": user.EmailVerifiedAt,
+		},
+	})
+}
+
+// ResendVerification handles sending the current user a new verification link
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	if err := h.accountService.SendVerification(c.Request.Context(), userID.(uint)); err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Email is already verified"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ResetPasswordPage serves the form the password reset link opens
+func (h *AccountHandler) ResetPasswordPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "reset-password.html", gin.H{
+		"title": "Reset Password - Top Up Game",
+	})
+}
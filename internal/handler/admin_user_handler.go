@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type AdminUserHandler struct {
+	userAdminService service.UserAdminService
+	validator        *validator.Validate
+}
+
+func NewAdminUserHandler(userAdminService service.UserAdminService) *AdminUserHandler {
+	return &AdminUserHandler{
+		userAdminService: userAdminService,
+		validator:        validator.New(),
+	}
+}
+
+type CreateUserRequest struct {
+	Email    string     `json:"email" validate:"required,email"`
+	Password string     `json:"password" validate:"required,min=6"`
+	Role     model.Role `json:"role" validate:"required,oneof=admin reseller guest"`
+}
+
+type ChangeRoleRequest struct {
+	Role model.Role `json:"role" validate:"required,oneof=admin reseller guest"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	// Password is generated when left empty
+	Password string `json:"password" validate:"omitempty,min=6"`
+}
+
+// ListUsers handles fetching users (admin only)
+func (h *AdminUserHandler) ListUsers(c *gin.Context) {
+	params := repository.UserQueryParams{Limit: 50}
+
+	if role := c.Query("role"); role != "" {
+		userRole := model.Role(role)
+		params.Role = &userRole
+	}
+
+	if status := c.Query("status"); status != "" {
+		userStatus := model.UserStatus(status)
+		params.Status = &userStatus
+	}
+
+	params.Search = c.Query("search")
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			params.Offset = o
+		}
+	}
+
+	users, err := h.userAdminService.ListUsers(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// GetUser handles fetching a user (admin only)
+func (h *AdminUserHandler) GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userAdminService.GetUser(id)
+	if err != nil {
+		h.handleError(c, err, "Failed to fetch user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// CreateUser handles creating an account of any role, including admins
+// (admin only)
+func (h *AdminUserHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	user, err := h.userAdminService.CreateUser(actorID.(uint), req.Email, req.Password, req.Role)
+	if err != nil {
+		h.handleError(c, err, "Failed to create user")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User created",
+		"user":    user,
+	})
+}
+
+// ChangeRole handles changing a user's role (admin only)
+func (h *AdminUserHandler) ChangeRole(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ChangeRoleRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	user, err := h.userAdminService.ChangeRole(actorID.(uint), id, req.Role)
+	if err != nil {
+		h.handleError(c, err, "Failed to change role")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role changed",
+		"user":    user,
+	})
+}
+
+// SuspendUser handles suspending a user, which also logs them out (admin only)
+func (h *AdminUserHandler) SuspendUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SuspendUserRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	user, err := h.userAdminService.Suspend(actorID.(uint), id, req.Reason)
+	if err != nil {
+		h.handleError(c, err, "Failed to suspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User suspended",
+		"user":    user,
+	})
+}
+
+// UnsuspendUser handles lifting a user's suspension (admin only)
+func (h *AdminUserHandler) UnsuspendUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	user, err := h.userAdminService.Unsuspend(actorID.(uint), id)
+	if err != nil {
+		h.handleError(c, err, "Failed to unsuspend user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unsuspended",
+		"user":    user,
+	})
+}
+
+// ResetPassword handles setting a new password for a user, generating one
+// when none is given (admin only)
+func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	password, err := h.userAdminService.ResetPassword(actorID.(uint), id, req.Password)
+	if err != nil {
+		h.handleError(c, err, "Failed to reset password")
+		return
+	}
+
+	response := gin.H{"message": "Password reset, all sessions of the user were logged out"}
+	if password != "" {
+		response["password"] = password
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteUser handles soft deleting a user (admin only)
+func (h *AdminUserHandler) DeleteUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	if err := h.userAdminService.DeleteUser(actorID.(uint), id); err != nil {
+		h.handleError(c, err, "Failed to delete user")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// ListAuditLogs handles fetching the audit log, optionally for one actor or
+// target (admin only)
+func (h *AdminUserHandler) ListAuditLogs(c *gin.Context) {
+	params := repository.AuditLogQueryParams{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		Limit:      50,
+	}
+
+	if actorID := c.Query("actor_id"); actorID != "" {
+		if id, err := strconv.ParseUint(actorID, 10, 32); err == nil {
+			actor := uint(id)
+			params.ActorID = &actor
+		}
+	}
+
+	if targetID := c.Query("target_id"); targetID != "" {
+		if id, err := strconv.ParseUint(targetID, 10, 32); err == nil {
+			target := uint(id)
+			params.TargetID = &target
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			params.Offset = o
+		}
+	}
+
+	logs, err := h.userAdminService.GetAuditLogs(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+}
+
+// GetUserAuditLogs handles fetching the audit log of a user (admin only)
+func (h *AdminUserHandler) GetUserAuditLogs(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	logs, err := h.userAdminService.GetAuditLogs(repository.AuditLogQueryParams{
+		TargetType: model.AuditTargetUser,
+		TargetID:   &id,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": logs})
+}
+
+func (h *AdminUserHandler) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *AdminUserHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, repository.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+	case errors.Is(err, service.ErrCannotModifySelf):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrSuspensionReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func userIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
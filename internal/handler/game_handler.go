@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type GameHandler struct {
+	gameService      service.GameService
+	accountValidator service.GameAccountValidator
+	validator        *validator.Validate
+}
+
+func NewGameHandler(gameService service.GameService, accountValidator service.GameAccountValidator) *GameHandler {
+	return &GameHandler{
+		gameService:      gameService,
+		accountValidator: accountValidator,
+		validator:        validator.New(),
+	}
+}
+
+type ValidateAccountRequest struct {
+	GameID     string `json:"game_id" validate:"required"`
+	GameServer string `json:"game_server"`
+}
+
+type GameRequest struct {
+	Slug         string                 `json:"slug" validate:"required"`
+	Name         string                 `json:"name" validate:"required"`
+	Icon         string                 `json:"icon"`
+	InputFields  []model.GameInputField `json:"input_fields" validate:"required,min=1"`
+	NicknameCode string                 `json:"nickname_code"`
+	IsActive     *bool                  `json:"is_active"`
+	SortOrder    int                    `json:"sort_order"`
+}
+
+func (r GameRequest) game() *model.Game {
+	return &model.Game{
+		Slug:         r.Slug,
+		Name:         r.Name,
+		Icon:         r.Icon,
+		InputFields:  r.InputFields,
+		NicknameCode: r.NicknameCode,
+		IsActive:     r.IsActive == nil || *r.IsActive,
+		SortOrder:    r.SortOrder,
+	}
+}
+
+// ListGames handles fetching the active games of the storefront
+func (h *GameHandler) ListGames(c *gin.Context) {
+	games, err := h.gameService.GetGames(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"games": games})
+}
+
+// GetGameProducts handles fetching a game with the products that top it up
+func (h *GameHandler) GetGameProducts(c *gin.Context) {
+	game, products, err := h.gameService.GetGameProducts(c.Param("slug"))
+	if err != nil {
+		if errors.Is(err, repository.ErrGameNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game":     game,
+		"products": products,
+	})
+}
+
+// ValidateAccount handles checking a game account before checkout
+func (h *GameHandler) ValidateAccount(c *gin.Context) {
+	var req ValidateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	account, err := h.accountValidator.Validate(c.Request.Context(), service.GameAccount{
+		Game:       c.Param("slug"),
+		GameID:     req.GameID,
+		GameServer: req.GameServer,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGameAccount) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"valid": false, "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate game account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":    true,
+		"nickname": account.Nickname,
+		"verified": account.Verified,
+	})
+}
+
+// AdminListGames handles fetching all games, including inactive ones (admin only)
+func (h *GameHandler) AdminListGames(c *gin.Context) {
+	games, err := h.gameService.GetGames(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"games": games})
+}
+
+// GetGame handles fetching a single game (admin only)
+func (h *GameHandler) GetGame(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		return
+	}
+
+	game, err := h.gameService.GetGame(uint(id))
+	if err != nil {
+		h.handleError(c, err, "Failed to fetch game")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"game": game})
+}
+
+// CreateGame handles adding a game to the catalog (admin only)
+func (h *GameHandler) CreateGame(c *gin.Context) {
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	game := req.game()
+	if err := h.gameService.CreateGame(game); err != nil {
+		h.handleError(c, err, "Failed to create game")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"game": game})
+}
+
+// UpdateGame handles replacing a game (admin only)
+func (h *GameHandler) UpdateGame(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		return
+	}
+
+	req, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	game := req.game()
+	game.ID = uint(id)
+	if err := h.gameService.UpdateGame(game); err != nil {
+		h.handleError(c, err, "Failed to update game")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"game": game})
+}
+
+// DeleteGame handles removing a game without products (admin only)
+func (h *GameHandler) DeleteGame(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game ID"})
+		return
+	}
+
+	if err := h.gameService.DeleteGame(uint(id)); err != nil {
+		h.handleError(c, err, "Failed to delete game")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Game deleted successfully"})
+}
+
+func (h *GameHandler) bind(c *gin.Context) (*GameRequest, bool) {
+	var req GameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return nil, false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func (h *GameHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrGameNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+	case errors.Is(err, service.ErrInvalidGame):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrGameExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Game slug already taken"})
+	case errors.Is(err, repository.ErrGameInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "Game still has products"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CallbackSignatureHeader carries the HMAC-SHA256 signature of the callback body
+const CallbackSignatureHeader = "X-Callback-Signature"
+
+type PaymentHandler struct {
+	paymentGateway     service.PaymentGateway
+	transactionService service.TransactionService
+	walletService      service.WalletService
+}
+
+func NewPaymentHandler(paymentGateway service.PaymentGateway, transactionService service.TransactionService, walletService service.WalletService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentGateway:     paymentGateway,
+		transactionService: transactionService,
+		walletService:      walletService,
+	}
+}
+
+// Callback handles payment notifications pushed by the payment provider
+func (h *PaymentHandler) Callback(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	callback, err := h.paymentGateway.ParseCallback(payload, c.GetHeader(CallbackSignatureHeader))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid callback payload"})
+		return
+	}
+
+	if service.IsTopUpInvoice(callback.Invoice) {
+		h.topUpCallback(c, *callback)
+		return
+	}
+
+	transaction, err := h.transactionService.ConfirmPayment(c.Request.Context(), *callback)
+	if errors.Is(err, service.ErrLatePayment) {
+		// Acknowledged so that the provider stops redelivering it
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Late payment recorded for review",
+			"invoice":      transaction.Invoice,
+			"status":       transaction.Status,
+			"needs_review": true,
+		})
+		return
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, service.ErrPaymentAmountMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paid amount does not match transaction amount"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment callback"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Callback processed",
+		"invoice": transaction.Invoice,
+		"status":  transaction.Status,
+	})
+}
+
+// topUpCallback handles payment notifications of wallet top-ups
+func (h *PaymentHandler) topUpCallback(c *gin.Context, callback service.PaymentCallback) {
+	topUp, err := h.walletService.ConfirmTopUp(callback)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTopUpNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Top-up not found"})
+		case errors.Is(err, service.ErrPaymentAmountMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paid amount does not match top-up amount"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process payment callback"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Callback processed",
+		"invoice": topUp.Invoice,
+		"status":  topUp.Status,
+	})
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type PricingHandler struct {
+	pricingService service.PricingService
+	validator      *validator.Validate
+}
+
+func NewPricingHandler(pricingService service.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+		validator:      validator.New(),
+	}
+}
+
+type MarginRuleRequest struct {
+	Category    string      `json:"category"`
+	Type        string      `json:"type" validate:"required,oneof=percent fixed"`
+	BasisPoints int64       `json:"basis_points" validate:"min=0"`
+	Amount      model.Money `json:"amount"`
+}
+
+type PriceTierRequest struct {
+	Name        string              `json:"name" validate:"required"`
+	Description string              `json:"description"`
+	Role        *model.Role         `json:"role" validate:"omitempty,oneof=guest reseller admin"`
+	UserID      *uint               `json:"user_id"`
+	IsActive    *bool               `json:"is_active"`
+	Rules       []MarginRuleRequest `json:"rules" validate:"dive"`
+}
+
+func (r PriceTierRequest) tier() *model.PriceTier {
+	tier := &model.PriceTier{
+		Name:        r.Name,
+		Description: r.Description,
+		Role:        r.Role,
+		UserID:      r.UserID,
+		IsActive:    r.IsActive == nil || *r.IsActive,
+		Rules:       make([]model.MarginRule, 0, len(r.Rules)),
+	}
+	for _, rule := range r.Rules {
+		tier.Rules = append(tier.Rules, model.MarginRule{
+			Category:    rule.Category,
+			Type:        model.MarginType(rule.Type),
+			BasisPoints: rule.BasisPoints,
+			Amount:      rule.Amount,
+		})
+	}
+	return tier
+}
+
+// ListTiers handles fetching all price tiers
+func (h *PricingHandler) ListTiers(c *gin.Context) {
+	tiers, err := h.pricingService.GetTiers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price tiers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tiers": tiers})
+}
+
+// GetTier handles fetching a single price tier
+func (h *PricingHandler) GetTier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price tier ID"})
+		return
+	}
+
+	tier, err := h.pricingService.GetTier(uint(id))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tier": tier})
+}
+
+// CreateTier handles creating a new price tier
+func (h *PricingHandler) CreateTier(c *gin.Context) {
+	var req PriceTierRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	tier := req.tier()
+	if err := h.pricingService.CreateTier(tier); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"tier": tier})
+}
+
+// UpdateTier handles replacing an existing price tier and its rules
+func (h *PricingHandler) UpdateTier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price tier ID"})
+		return
+	}
+
+	var req PriceTierRequest
+	if !h.bind(c, &req) {
+		return
+	}
+
+	tier := req.tier()
+	tier.ID = uint(id)
+	if err := h.pricingService.UpdateTier(tier); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tier": tier})
+}
+
+// DeleteTier handles deleting a price tier
+func (h *PricingHandler) DeleteTier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price tier ID"})
+		return
+	}
+
+	if err := h.pricingService.DeleteTier(uint(id)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Price tier deleted successfully"})
+}
+
+func (h *PricingHandler) bind(c *gin.Context, req *PriceTierRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *PricingHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrPriceTierNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price tier not found"})
+	case errors.Is(err, service.ErrInvalidPriceTier):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPriceTierConflict), errors.Is(err, repository.ErrPriceTierExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save price tier"})
+	}
+}
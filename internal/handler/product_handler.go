@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"topup-game/internal/model"
@@ -24,20 +25,35 @@ func NewProductHandler(productService service.ProductService) *ProductHandler {
 }
 
 type CreateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Category    string  `json:"category" validate:"required"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Description string  `json:"description"`
-	SKU         string  `json:"sku" validate:"required"`
-	Stock       int     `json:"stock" validate:"min=0"`
+	Name        string      `json:"name" validate:"required"`
+	Category    string      `json:"category" validate:"required"`
+	GameID      *uint       `json:"game_id"`
+	Price       model.Money `json:"price"`
+	Cost        model.Money `json:"cost"`
+	Description string      `json:"description"`
+	SKU         string      `json:"sku" validate:"required"`
+	Stock       int         `json:"stock" validate:"min=0"`
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Category    string  `json:"category" validate:"required"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	Description string  `json:"description"`
-	IsActive    bool    `json:"is_active"`
+	Name        string       `json:"name" validate:"required"`
+	Category    string       `json:"category" validate:"required"`
+	GameID      *uint        `json:"game_id"`
+	Price       model.Money  `json:"price"`
+	Cost        *model.Money `json:"cost"`
+	Description string       `json:"description"`
+	IsActive    bool         `json:"is_active"`
+}
+
+type ProductSupplierRequest struct {
+	Supplier string `json:"supplier" validate:"required"`
+	SKU      string `json:"sku" validate:"required"`
+	Priority int    `json:"priority"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type SetProductSuppliersRequest struct {
+	Suppliers []ProductSupplierRequest `json:"suppliers" validate:"dive"`
 }
 
 // ListProducts handles fetching all products
@@ -92,7 +108,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	product := &model.Product{
 		Name:        req.Name,
 		Category:    req.Category,
+		GameID:      req.GameID,
 		Price:       req.Price,
+		Cost:        req.Cost,
 		Description: req.Description,
 		SKU:         req.SKU,
 		Stock:       req.Stock,
@@ -100,11 +118,19 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	}
 
 	if err := h.productService.CreateProduct(product); err != nil {
+		if errors.Is(err, service.ErrInvalidProduct) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, repository.ErrGameNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown game"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"product": product})
+	c.JSON(http.StatusCreated, gin.H{"product": product, "cost": product.Cost})
 }
 
 // UpdateProduct handles updating an existing product
@@ -135,16 +161,37 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		IsActive:    req.IsActive,
 	}
 
+	// Keep the current cost and game unless new ones are given
+	if req.Cost == nil || req.GameID == nil {
+		existing, err := h.productService.GetProductByID(product.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		product.Cost = existing.Cost
+		product.GameID = existing.GameID
+	}
+	if req.Cost != nil {
+		product.Cost = *req.Cost
+	}
+	if req.GameID != nil {
+		product.GameID = req.GameID
+	}
+
 	if err := h.productService.UpdateProduct(product); err != nil {
-		if err == service.ErrInvalidProduct {
+		if errors.Is(err, service.ErrInvalidProduct) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, repository.ErrGameNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown game"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"product": product})
+	c.JSON(http.StatusOK, gin.H{"product": product, "cost": product.Cost})
 }
 
 // GetProduct handles fetching a single product
@@ -180,14 +227,182 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
-// SyncProducts handles syncing products with VIP Reseller
+// SyncProducts handles syncing products with VIP Reseller. The response
+// carries the report of the run, also when it failed. With dry_run=true the
+// report is computed without changing any product.
 func (h *ProductHandler) SyncProducts(c *gin.Context) {
-	if err := h.productService.SyncProductsWithVIPReseller(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync products"})
+	var options service.SyncOptions
+	if userID, ok := c.Get("userID"); ok {
+		id := userID.(uint)
+		options.TriggeredBy = &id
+	}
+	if dryRun := c.Query("dry_run"); dryRun != "" {
+		var err error
+		if options.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run value"})
+			return
+		}
+	}
+
+	run, err := h.productService.SyncProductsWithVIPReseller(c.Request.Context(), options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync products", "run": run})
+		return
+	}
+
+	message := "Products synced successfully"
+	if options.DryRun {
+		message = "Dry run completed, no products were changed"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"run":     run,
+	})
+}
+
+// ListSyncRuns handles fetching past catalog syncs with their reports
+func (h *ProductHandler) ListSyncRuns(c *gin.Context) {
+	params := repository.SyncRunQueryParams{Limit: 20}
+
+	if status := c.Query("status"); status != "" {
+		runStatus := model.SyncRunStatus(status)
+		params.Status = &runStatus
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			params.Offset = o
+		}
+	}
+
+	runs, err := h.productService.GetSyncRuns(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sync runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// MarginAlerts handles listing products whose price fell below the minimum
+// margin over supplier cost
+func (h *ProductHandler) MarginAlerts(c *gin.Context) {
+	marginAlert := true
+	products, err := h.productService.GetProducts(repository.ProductQueryParams{
+		MarginAlert: &marginAlert,
+		SortBy:      "category",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		return
+	}
+
+	alerts := make([]gin.H, 0, len(products))
+	for _, product := range products {
+		alerts = append(alerts, gin.H{"product": product, "cost": product.Cost})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+// RepriceProduct handles pricing a product from its supplier cost with the
+// markup policy
+func (h *ProductHandler) RepriceProduct(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	product, err := h.productService.RepriceProduct(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, service.ErrUnknownCost):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reprice product"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"product": product, "cost": product.Cost})
+}
+
+// GetProductSuppliers handles fetching the suppliers a product is ordered from
+func (h *ProductHandler) GetProductSuppliers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	suppliers, err := h.productService.GetProductSuppliers(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product suppliers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suppliers": suppliers})
+}
+
+// SetProductSuppliers handles replacing the suppliers a product is ordered from
+func (h *ProductHandler) SetProductSuppliers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return
+	}
+
+	var req SetProductSuppliersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	suppliers := make([]model.ProductSupplier, 0, len(req.Suppliers))
+	for _, supplier := range req.Suppliers {
+		isActive := true
+		if supplier.IsActive != nil {
+			isActive = *supplier.IsActive
+		}
+		suppliers = append(suppliers, model.ProductSupplier{
+			Supplier: supplier.Supplier,
+			SKU:      supplier.SKU,
+			Priority: supplier.Priority,
+			IsActive: isActive,
+		})
+	}
+
+	if err := h.productService.SetProductSuppliers(uint(id), suppliers); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		case errors.Is(err, service.ErrInvalidMapping):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product suppliers"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Products synced successfully"})
+	c.JSON(http.StatusOK, gin.H{"suppliers": suppliers})
 }
 
 // RegisterRoutes registers the product routes
@@ -204,5 +419,6 @@ func (h *ProductHandler) RegisterRoutes(router *gin.Engine, authMiddleware gin.H
 		admin.PUT("/:id", h.UpdateProduct)
 		admin.DELETE("/:id", h.DeleteProduct)
 		admin.POST("/sync", h.SyncProducts)
+		admin.GET("/sync/runs", h.ListSyncRuns)
 	}
 }
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type RefundHandler struct {
+	refundService service.RefundService
+	validator     *validator.Validate
+}
+
+func NewRefundHandler(refundService service.RefundService) *RefundHandler {
+	return &RefundHandler{
+		refundService: refundService,
+		validator:     validator.New(),
+	}
+}
+
+type RefundRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// RefundTransaction handles fully refunding a transaction (admin only)
+func (h *RefundHandler) RefundTransaction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	refund, err := h.refundService.RefundTransaction(uint(id), actorID.(uint), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, service.ErrNotRefundable):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrRefundExists):
+			c.JSON(http.StatusConflict, gin.H{"error": "Transaction already has a refund"})
+		case errors.Is(err, service.ErrRefundFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund transaction"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Transaction refunded",
+		"refund":  refund,
+	})
+}
+
+// ListRefunds handles fetching refunds (admin only)
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	var params repository.RefundQueryParams
+
+	if status := c.Query("status"); status != "" {
+		refundStatus := model.RefundStatus(status)
+		params.Status = &refundStatus
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			params.Offset = o
+		}
+	}
+
+	refunds, err := h.refundService.GetRefunds(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
+
+// GetRefund handles fetching a single refund (admin only)
+func (h *RefundHandler) GetRefund(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund ID"})
+		return
+	}
+
+	refund, err := h.refundService.GetRefund(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefundNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refund not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refund": refund})
+}
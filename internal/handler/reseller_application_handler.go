@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type ResellerApplicationHandler struct {
+	applicationService service.ResellerApplicationService
+	validator          *validator.Validate
+}
+
+func NewResellerApplicationHandler(applicationService service.ResellerApplicationService) *ResellerApplicationHandler {
+	return &ResellerApplicationHandler{
+		applicationService: applicationService,
+		validator:          validator.New(),
+	}
+}
+
+type ResellerApplicationRequest struct {
+	BusinessName string `json:"business_name" validate:"required,max=255"`
+	Phone        string `json:"phone" validate:"required,max=30"`
+	Message      string `json:"message"`
+}
+
+type ReviewApplicationRequest struct {
+	Note string `json:"note"`
+}
+
+// Apply handles the current user applying to become a reseller
+func (h *ResellerApplicationHandler) Apply(c *gin.Context) {
+	var req ResellerApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	application := &model.ResellerApplication{
+		BusinessName: req.BusinessName,
+		Phone:        req.Phone,
+		Message:      req.Message,
+	}
+	if err := h.applicationService.Apply(userID.(uint), application); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidApplication):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNotEligible):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrApplicationPending):
+			c.JSON(http.StatusConflict, gin.H{"error": "You already have a pending application"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit application"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "Application submitted",
+		"application": application,
+	})
+}
+
+// GetMyApplication handles fetching the current user's latest application
+func (h *ResellerApplicationHandler) GetMyApplication(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	application, err := h.applicationService.GetUserApplication(userID.(uint))
+	if err != nil {
+		if errors.Is(err, repository.ErrApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No reseller application found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch application"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"application": application})
+}
+
+// ListApplications handles fetching reseller applications (admin only)
+func (h *ResellerApplicationHandler) ListApplications(c *gin.Context) {
+	var params repository.ResellerApplicationQueryParams
+
+	if status := c.Query("status"); status != "" {
+		applicationStatus := model.ApplicationStatus(status)
+		params.Status = &applicationStatus
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err == nil {
+			params.Offset = o
+		}
+	}
+
+	applications, err := h.applicationService.GetApplications(params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch applications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applications": applications})
+}
+
+// GetApplication handles fetching a reseller application (admin only)
+func (h *ResellerApplicationHandler) GetApplication(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	application, err := h.applicationService.GetApplication(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrApplicationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch application"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"application": application})
+}
+
+// ApproveApplication handles approving a reseller application (admin only)
+func (h *ResellerApplicationHandler) ApproveApplication(c *gin.Context) {
+	h.review(c, h.applicationService.Approve, "Application approved")
+}
+
+// RejectApplication handles rejecting a reseller application (admin only)
+func (h *ResellerApplicationHandler) RejectApplication(c *gin.Context) {
+	h.review(c, h.applicationService.Reject, "Application rejected")
+}
+
+func (h *ResellerApplicationHandler) review(c *gin.Context, decide func(id, reviewerID uint, note string) (*model.ResellerApplication, error), message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	var req ReviewApplicationRequest
+	// The note is optional for approvals, so an empty body is fine
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	reviewerID, _ := c.Get("userID")
+	application, err := decide(uint(id), reviewerID.(uint), req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrApplicationNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Application not found"})
+		case errors.Is(err, service.ErrReviewNoteRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrApplicationReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review application"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     message,
+		"application": application,
+	})
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"topup-game/internal/model"
@@ -24,10 +26,10 @@ func NewTransactionHandler(transactionService service.TransactionService) *Trans
 }
 
 type CheckoutRequest struct {
-	ProductID   uint   `json:"product_id" validate:"required"`
-	GameID      string `json:"game_id" validate:"required"`
-	GameServer  string `json:"game_server" validate:"required"`
-	Method      string `json:"method" validate:"required,oneof=bank_transfer ewallet credit_card"`
+	ProductID  uint   `json:"product_id" validate:"required"`
+	GameID     string `json:"game_id" validate:"required"`
+	GameServer string `json:"game_server"`
+	Method     string `json:"method" validate:"required,oneof=bank_transfer ewallet credit_card balance"`
 }
 
 // Checkout handles creating a new transaction
@@ -58,13 +60,31 @@ func (h *TransactionHandler) Checkout(c *gin.Context) {
 		Method:     req.Method,
 	}
 
-	transaction, err := h.transactionService.ProcessCheckout(checkout)
+	transaction, err := h.transactionService.ProcessCheckout(c.Request.Context(), checkout)
+	if err != nil && transaction != nil {
+		// The transaction exists, possibly paid for, so a retry must not
+		// create another one
+		c.Set("requestPersisted", true)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to process checkout",
+			"invoice": transaction.Invoice,
+		})
+		return
+	}
 	if err != nil {
-		switch err {
-		case service.ErrProductUnavailable:
+		switch {
+		case errors.Is(err, service.ErrProductUnavailable):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is currently unavailable"})
-		case service.ErrInvalidTransaction:
+		case errors.Is(err, service.ErrProviderUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Provider unavailable, please try again later"})
+		case errors.Is(err, service.ErrInvalidTransaction):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction data"})
+		case errors.Is(err, service.ErrInvalidGameAccount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrLoginRequired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Log in to pay from your balance"})
+		case errors.Is(err, repository.ErrInsufficientFunds):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Insufficient balance"})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process checkout"})
 		}
@@ -79,6 +99,13 @@ func (h *TransactionHandler) Checkout(c *gin.Context) {
 			"amount":  transaction.Amount,
 			"status":  transaction.Status,
 		},
+		"payment": gin.H{
+			"provider":    transaction.PaymentProvider,
+			"reference":   transaction.PaymentReference,
+			"payment_url": transaction.PaymentURL,
+			"va_number":   transaction.VANumber,
+			"expires_at":  transaction.PaymentExpiresAt,
+		},
 	})
 }
 
@@ -90,9 +117,16 @@ func (h *TransactionHandler) GetTransactionStatus(c *gin.Context) {
 		return
 	}
 
-	// Sync status with VIP Reseller
-	if err := h.transactionService.SyncTransactionStatus(invoice); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync transaction status"})
+	// Sync status with the supplier
+	if err := h.transactionService.SyncTransactionStatus(c.Request.Context(), invoice); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInvoice):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice number, please check it for typos"})
+		case errors.Is(err, repository.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync transaction status"})
+		}
 		return
 	}
 
@@ -132,6 +166,12 @@ func (h *TransactionHandler) ListTransactions(c *gin.Context) {
 		params.Status = &transStatus
 	}
 
+	if needsReview := c.Query("needs_review"); needsReview != "" {
+		if b, err := strconv.ParseBool(needsReview); err == nil {
+			params.NeedsReview = &b
+		}
+	}
+
 	if limit := c.Query("limit"); limit != "" {
 		if l, err := strconv.Atoi(limit); err == nil {
 			params.Limit = l
@@ -170,6 +210,127 @@ func (h *TransactionHandler) GetTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"transaction": transaction})
 }
 
+// GetTransactionEvents handles fetching the status history of a transaction (admin only)
+func (h *TransactionHandler) GetTransactionEvents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	events, err := h.transactionService.GetTransactionEvents(uint(id))
+	if err != nil {
+		if err == repository.ErrTransactionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transaction events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+type CancelTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelTransaction handles cancelling a transaction awaiting payment (admin only)
+func (h *TransactionHandler) CancelTransaction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req CancelTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	uid := actorID.(uint)
+	transaction, err := h.transactionService.CancelTransaction(uint(id), service.StatusChange{
+		Source:  model.EventSourceAdmin,
+		ActorID: &uid,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Only transactions awaiting payment can be cancelled"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel transaction"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Transaction cancelled",
+		"transaction": transaction,
+	})
+}
+
+type ResolveReviewRequest struct {
+	SupplierOrderID string `json:"supplier_order_id"`
+	State           string `json:"state" validate:"omitempty,oneof=success failed"`
+	Reason          string `json:"reason"`
+}
+
+// ResolveReview handles settling an order whose supplier outcome was unknown
+// (admin only): either attach the order found at the supplier or mark the
+// order as delivered or failed
+func (h *TransactionHandler) ResolveReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var req ResolveReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	uid := actorID.(uint)
+	transaction, err := h.transactionService.ResolveReview(uint(id), service.ReviewResolution{
+		SupplierOrderID: req.SupplierOrderID,
+		State:           service.SupplierOrderState(req.State),
+	}, service.StatusChange{
+		Source:  model.EventSourceAdmin,
+		ActorID: &uid,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		case errors.Is(err, service.ErrNotUnderReview):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Transaction is not awaiting review"})
+		case errors.Is(err, service.ErrInvalidResolution):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve transaction"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Transaction resolved",
+		"transaction": transaction,
+	})
+}
+
 // GetUserTransactions handles fetching transactions for the authenticated user
 func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -190,7 +351,6 @@ func (h *TransactionHandler) GetUserTransactions(c *gin.Context) {
 // RegisterRoutes registers the transaction routes
 func (h *TransactionHandler) RegisterRoutes(router *gin.Engine, authMiddleware, adminMiddleware gin.HandlerFunc) {
 	// Public routes
-	router.POST("/checkout", h.Checkout)
 	router.GET("/transaction/:invoice", h.GetTransactionStatus)
 
 	// Protected routes
@@ -206,5 +366,6 @@ func (h *TransactionHandler) RegisterRoutes(router *gin.Engine, authMiddleware,
 	{
 		admin.GET("", h.ListTransactions)
 		admin.GET("/:id", h.GetTransaction)
+		admin.GET("/:id/events", h.GetTransactionEvents)
 	}
 }
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"topup-game/internal/model"
+	"topup-game/internal/repository"
 	"topup-game/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -10,14 +13,16 @@ import (
 )
 
 type UserHandler struct {
-	userService service.UserService
-	validator   *validator.Validate
+	userService    service.UserService
+	accountService service.AccountService
+	validator      *validator.Validate
 }
 
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, accountService service.AccountService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		validator:   validator.New(),
+		userService:    userService,
+		accountService: accountService,
+		validator:      validator.New(),
 	}
 }
 
@@ -26,10 +31,20 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+// RegisterRequest is the public sign-up form. It has no role: everyone
+// signs up as a customer and becomes a reseller through an application.
 type RegisterRequest struct {
-	Email    string     `json:"email" validate:"required,email"`
-	Password string     `json:"password" validate:"required,min=6"`
-	Role     model.Role `json:"role" validate:"required,oneof=admin reseller"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
 }
 
 // Login handles user authentication
@@ -45,19 +60,106 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.userService.Login(req.Email, req.Password)
+	tokens, err := h.userService.Login(req.Email, req.Password)
 	if err != nil {
-		if err == service.ErrInvalidCredentials {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		case errors.Is(err, service.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Refresh handles exchanging a refresh token for a new token pair
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	tokens, err := h.userService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		case errors.Is(err, service.ErrAccountSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Logout handles ending the session of the presented access token
+func (h *UserHandler) Logout(c *gin.Context) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.userService.Logout(claims.(*service.Claims)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ChangePassword handles changing the current user's password. Every session
+// of the user is logged out and the response carries the tokens of a new one.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	tokens, err := h.userService.ChangePassword(userID.(uint), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Current password is incorrect"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   token,
+		"message":       "Password changed, all other sessions were logged out",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
@@ -74,18 +176,26 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Register(req.Email, req.Password, req.Role)
+	user, err := h.userService.Register(req.Email, req.Password, model.RoleGuest)
 	if err != nil {
-		if err == service.ErrInvalidCredentials {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration data"})
-			return
+		case errors.Is(err, repository.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
 	}
 
+	// A failed email does not fail the sign-up; the user can ask for a new link
+	if err := h.accountService.SendVerification(c.Request.Context(), user.ID); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "Registration successful",
+		"message": "Registration successful, check your email to verify your address",
 		"user": gin.H{
 			"id":    user.ID,
 			"email": user.Email,
@@ -110,9 +220,10 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"user": gin.H{
-			"id":    user.ID,
-			"email": user.Email,
-			"role":  user.Role,
+			"id":                user.ID,
+			"email":             user.Email,
+			"role":              user.Role,
+			"email_verified_at": user.EmailVerifiedAt,
 		},
 	})
 }
@@ -123,6 +234,7 @@ func (h *UserHandler) RegisterRoutes(router *gin.Engine) {
 	{
 		auth.POST("/login", h.Login)
 		auth.POST("/register", h.Register)
+		auth.POST("/refresh", h.Refresh)
 	}
 
 	protected := router.Group("/user")
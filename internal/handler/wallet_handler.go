@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+type WalletHandler struct {
+	walletService service.WalletService
+	validator     *validator.Validate
+}
+
+func NewWalletHandler(walletService service.WalletService) *WalletHandler {
+	return &WalletHandler{
+		walletService: walletService,
+		validator:     validator.New(),
+	}
+}
+
+type TopUpRequest struct {
+	Amount model.Money `json:"amount"`
+	Method string      `json:"method" validate:"required,oneof=bank_transfer ewallet credit_card"`
+}
+
+type AdjustBalanceRequest struct {
+	// Amount is credited when positive and debited when negative
+	Amount model.Money `json:"amount"`
+	Reason string      `json:"reason" validate:"required"`
+}
+
+// GetWallet handles fetching the current user's wallet
+func (h *WalletHandler) GetWallet(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	wallet, err := h.walletService.GetWallet(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallet"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wallet": wallet})
+}
+
+// GetEntries handles fetching the ledger entries of the current user's wallet
+func (h *WalletHandler) GetEntries(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	entries, err := h.walletService.GetEntries(userID.(uint), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallet entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// GetTopUps handles fetching the current user's top-ups
+func (h *WalletHandler) GetTopUps(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	topUps, err := h.walletService.GetTopUps(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top-ups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"top_ups": topUps})
+}
+
+// CreateTopUp handles starting a deposit into the current user's wallet
+func (h *WalletHandler) CreateTopUp(c *gin.Context) {
+	var req TopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	topUp, err := h.walletService.CreateTopUp(userID.(uint), req.Amount, req.Method)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTopUp) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create top-up"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Top-up created",
+		"top_up":  topUp,
+	})
+}
+
+// AdjustBalance handles manual credits and debits of a user's wallet by an admin
+func (h *WalletHandler) AdjustBalance(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req AdjustBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	actorID, _ := c.Get("userID")
+	wallet, err := h.walletService.Adjust(uint(userID), req.Amount, actorID.(uint), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAdjustment), errors.Is(err, service.ErrReasonRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrInsufficientFunds):
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient balance"})
+		case errors.Is(err, gorm.ErrForeignKeyViolated):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust balance"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Balance adjusted",
+		"wallet":  wallet,
+	})
+}
+
+// GetUserWallet handles fetching a user's wallet for admins
+func (h *WalletHandler) GetUserWallet(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	wallet, err := h.walletService.GetWallet(uint(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrForeignKeyViolated) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallet"})
+		return
+	}
+
+	entries, err := h.walletService.GetEntries(uint(userID), 50, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wallet entries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wallet":  wallet,
+		"entries": entries,
+	})
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"topup-game/internal/repository"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VIPSignatureHeader carries the HMAC-SHA256 signature of a VIP Reseller webhook body
+const VIPSignatureHeader = "X-VIP-Signature"
+
+type WebhookHandler struct {
+	vipReseller        service.VIPResellerService
+	transactionService service.TransactionService
+}
+
+func NewWebhookHandler(vipReseller service.VIPResellerService, transactionService service.TransactionService) *WebhookHandler {
+	return &WebhookHandler{
+		vipReseller:        vipReseller,
+		transactionService: transactionService,
+	}
+}
+
+// VIPReseller handles order status notifications pushed by VIP Reseller
+func (h *WebhookHandler) VIPReseller(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	status, err := h.vipReseller.ParseWebhook(payload, c.GetHeader(VIPSignatureHeader))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSignature) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		return
+	}
+
+	transaction, err := h.transactionService.ApplyVIPStatus(*status, string(payload))
+	if err != nil {
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook processed",
+		"invoice": transaction.Invoice,
+		"status":  transaction.Status,
+	})
+}
@@ -0,0 +1,28 @@
+// Package mailer sends transactional email such as password reset links
+package mailer
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrInvalidMessage = errors.New("invalid mail message")
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+func (m Message) validate() error {
+	if m.To == "" || m.Subject == "" {
+		return ErrInvalidMessage
+	}
+	return nil
+}
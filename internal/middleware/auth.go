@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"topup-game/internal/model"
 	"topup-game/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -34,9 +35,24 @@ func AuthMiddleware(userService service.UserService) gin.HandlerFunc {
 			return
 		}
 
+		// The account may have been suspended or deleted since the token
+		// was issued
+		user, err := userService.GetUserByID(claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+		if user.Status == model.UserSuspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		c.Set("userID", user.ID)
+		c.Set("userRole", user.Role)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
@@ -84,9 +100,16 @@ func OptionalAuthMiddleware(userService service.UserService) gin.HandlerFunc {
 			return
 		}
 
+		// Suspended users shop as guests
+		user, err := userService.GetUserByID(claims.UserID)
+		if err != nil || user.Status == model.UserSuspended {
+			c.Next()
+			return
+		}
+
 		// Set user info in context
-		c.Set("userID", claims.UserID)
-		c.Set("userRole", claims.Role)
+		c.Set("userID", user.ID)
+		c.Set("userRole", user.Role)
 		c.Next()
 	}
 }
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"topup-game/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// bodyCaptureWriter keeps a copy of everything written to the response
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency creates a gin middleware that makes requests carrying an
+// Idempotency-Key header safe to retry: the first response is stored and
+// returned again for retries with the same key and body
+func Idempotency(idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key header is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := idempotencyService.Begin(idempotencyScope(c), key, hashRequestBody(body))
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case errors.Is(err, service.ErrRequestInProgress):
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			}
+			c.Abort()
+			return
+		}
+
+		// Replay the stored response of the original request
+		if record.IsCompleted() {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.ResponseStatus, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		// A handler that panics has an unknown outcome; its key is released
+		// rather than left pending until it expires
+		finished := false
+		defer func() {
+			if !finished {
+				_ = idempotencyService.Release(record)
+			}
+		}()
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		finished = true
+
+		// Server errors are not stored so that the client can retry them,
+		// unless the handler set requestPersisted: then a retry would repeat
+		// what was already saved
+		if c.Writer.Status() >= http.StatusInternalServerError && !c.GetBool("requestPersisted") {
+			if err := idempotencyService.Release(record); err != nil {
+				_ = c.Error(err)
+			}
+			return
+		}
+		if err := idempotencyService.Complete(record, c.Writer.Status(), writer.body.Bytes()); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// idempotencyScope keeps keys of different endpoints and users apart
+func idempotencyScope(c *gin.Context) string {
+	scope := c.Request.Method + " " + c.FullPath()
+	if userID, exists := c.Get("userID"); exists {
+		scope = fmt.Sprintf("%s user:%v", scope, userID)
+	}
+	return scope
+}
+
+// hashRequestBody hashes the body in canonical form when it is JSON, so that
+// retries differing only in whitespace or key order are the same request
+func hashRequestBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if canonical, err := json.Marshal(decoded); err == nil {
+			body = canonical
+		}
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
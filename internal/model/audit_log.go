@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Audit actions on users
+const (
+	AuditUserCreate        = "user.create"
+	AuditUserRoleChange    = "user.role_change"
+	AuditUserSuspend       = "user.suspend"
+	AuditUserUnsuspend     = "user.unsuspend"
+	AuditUserPasswordReset = "user.password_reset"
+	AuditUserDelete        = "user.delete"
+)
+
+// AuditTargetUser is the target type of audit logs about users
+const AuditTargetUser = "user"
+
+// AuditLog records an administrative action: who did what to which record
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    *uint     `gorm:"index" json:"actor_id,omitempty"`
+	Action     string    `gorm:"type:varchar(50);not null;index" json:"action"`
+	TargetType string    `gorm:"type:varchar(50);not null;index:idx_audit_logs_target" json:"target_type"`
+	TargetID   uint      `gorm:"not null;index:idx_audit_logs_target" json:"target_id"`
+	Details    string    `gorm:"type:text" json:"details,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName specifies the table name for the AuditLog model
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Checkout inputs a game can ask for
+const (
+	GameInputID     = "game_id"
+	GameInputServer = "game_server"
+)
+
+// GameInputField describes one account input the storefront asks for when
+// topping up a game, such as Mobile Legends' user ID and zone ID
+type GameInputField struct {
+	// Name is the checkout input the field fills: game_id or game_server
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Placeholder string `json:"placeholder,omitempty"`
+	Required    bool   `json:"required"`
+	// Pattern is a regular expression the value must match
+	Pattern string `json:"pattern,omitempty"`
+	// Options restricts the value to a fixed list, e.g. server regions
+	Options []string `json:"options,omitempty"`
+}
+
+// GameInputFields is stored as a JSON array
+type GameInputFields []GameInputField
+
+// Value implements driver.Valuer
+func (f GameInputFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(f)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (f *GameInputFields) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	}
+	return fmt.Errorf("cannot scan %T into GameInputFields", value)
+}
+
+// Game is a title products top up, with the account inputs it needs
+type Game struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Slug        string          `gorm:"type:varchar(100);uniqueIndex;not null" json:"slug"`
+	Name        string          `gorm:"not null" json:"name"`
+	Icon        string          `json:"icon,omitempty"`
+	InputFields GameInputFields `gorm:"type:jsonb;not null;default:'[]'" json:"input_fields"`
+	// NicknameCode is the supplier's code for account nickname lookups,
+	// empty when accounts of the game cannot be looked up
+	NicknameCode string    `json:"nickname_code,omitempty"`
+	IsActive     bool      `gorm:"not null" json:"is_active"`
+	SortOrder    int       `gorm:"not null;default:0" json:"sort_order"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Game model
+func (Game) TableName() string {
+	return "games"
+}
+
+// Field returns the input field with the given name, or nil
+func (g *Game) Field(name string) *GameInputField {
+	for i := range g.InputFields {
+		if g.InputFields[i].Name == name {
+			return &g.InputFields[i]
+		}
+	}
+	return nil
+}
+
+var gameSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Validate performs validation on game data
+func (g *Game) Validate() error {
+	if !gameSlugPattern.MatchString(g.Slug) {
+		return ErrInvalidGameSlug
+	}
+	if g.Name == "" {
+		return ErrGameNameRequired
+	}
+
+	seen := make(map[string]bool, len(g.InputFields))
+	for _, field := range g.InputFields {
+		if field.Name != GameInputID && field.Name != GameInputServer {
+			return ErrInvalidGameInput
+		}
+		if seen[field.Name] {
+			return ErrDuplicateGameInput
+		}
+		seen[field.Name] = true
+
+		if field.Label == "" {
+			return ErrGameInputLabelRequired
+		}
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return ValidationError{fmt.Sprintf("invalid pattern for %s: %v", field.Name, err)}
+		}
+	}
+	if field := g.Field(GameInputID); field == nil || !field.Required {
+		return ErrGameIDInputRequired
+	}
+	return nil
+}
+
+// Custom errors for game validation
+var (
+	ErrInvalidGameSlug        = ValidationError{"game slug must be lowercase letters and digits separated by dashes"}
+	ErrGameNameRequired       = ValidationError{"game name is required"}
+	ErrInvalidGameInput       = ValidationError{"game input fields must be game_id or game_server"}
+	ErrDuplicateGameInput     = ValidationError{"game input fields must not repeat"}
+	ErrGameInputLabelRequired = ValidationError{"game input fields need a label"}
+	ErrGameIDInputRequired    = ValidationError{"games need a required game_id input"}
+)
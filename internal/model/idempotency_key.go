@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// IdempotencyKey stores the outcome of a request sent with an Idempotency-Key
+// header so that retries of the same request can be answered from it
+type IdempotencyKey struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Scope          string     `gorm:"not null;uniqueIndex:idx_idempotency_scope_key" json:"scope"`
+	Key            string     `gorm:"not null;uniqueIndex:idx_idempotency_scope_key" json:"key"`
+	RequestHash    string     `gorm:"not null" json:"request_hash"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body"`
+	CompletedAt    *time.Time `json:"completed_at"`
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for the IdempotencyKey model
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// IsCompleted checks if the original request has finished and its response was stored
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.CompletedAt != nil
+}
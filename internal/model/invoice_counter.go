@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// InvoiceCounter is the last number handed out in an invoice sequence. Each
+// prefix and date has its own sequence.
+type InvoiceCounter struct {
+	Scope     string    `gorm:"type:varchar(100);primaryKey" json:"scope"`
+	Value     int64     `gorm:"not null" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the InvoiceCounter model
+func (InvoiceCounter) TableName() string {
+	return "invoice_counters"
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of every amount stored in the database
+const DefaultCurrency = "IDR"
+
+// moneyScale is the number of minor units in a major unit. Amounts keep two
+// decimals, the ISO 4217 exponent of IDR.
+const (
+	moneyDecimals = 2
+	moneyScale    = 100
+)
+
+var ErrInvalidMoney = errors.New("invalid money amount")
+
+// Money is an amount in integer minor units of its currency. It is stored in
+// a bigint column in DefaultCurrency and serialised to JSON as a decimal
+// string, so amounts never pass through floating point.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// NewMoney returns an amount of whole major units, e.g. rupiah
+func NewMoney(major int64, currency string) Money {
+	return Money{Minor: major * moneyScale, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "15000" or "15000.50"
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > moneyDecimals || strings.Trim(whole+fraction, "0123456789") != "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+	fraction += strings.Repeat("0", moneyDecimals-len(fraction))
+
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || major > math.MaxInt64/moneyScale {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, value)
+	}
+	minor, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := major*moneyScale + minor
+	if negative {
+		amount = -amount
+	}
+	return Money{Minor: amount, Currency: currency}, nil
+}
+
+// String formats the amount as a decimal string without the currency
+func (m Money) String() string {
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, minor/moneyScale, moneyDecimals, minor%moneyScale)
+}
+
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// Equal reports whether both amounts are the same in the same currency
+func (m Money) Equal(other Money) bool {
+	return m.Minor == other.Minor && m.currency() == other.currency()
+}
+
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// GormDataType stores Money as minor units in a bigint column
+func (Money) GormDataType() string {
+	return "bigint"
+}
+
+// Value implements driver.Valuer
+func (m Money) Value() (driver.Value, error) {
+	if m.currency() != DefaultCurrency {
+		return nil, fmt.Errorf("%w: cannot store %s amounts", ErrInvalidMoney, m.Currency)
+	}
+	return m.Minor, nil
+}
+
+// Scan implements sql.Scanner
+func (m *Money) Scan(value interface{}) error {
+	m.Currency = DefaultCurrency
+	switch v := value.(type) {
+	case nil:
+		m.Minor = 0
+	case int64:
+		m.Minor = v
+	case []byte:
+		minor, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+		}
+		m.Minor = minor
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidMoney, value)
+	}
+	return nil
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON writes {"amount":"15000.00","currency":"IDR"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.String(), m.currency()})
+}
+
+// UnmarshalJSON accepts the object written by MarshalJSON as well as a bare
+// number or decimal string in DefaultCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	value := moneyJSON{Currency: DefaultCurrency}
+	if len(data) > 0 && data[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+		}
+		if value.Currency == "" {
+			value.Currency = DefaultCurrency
+		}
+	} else {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMoney, err)
+		}
+		value.Amount = number
+	}
+
+	parsed, err := ParseMoney(value.Amount.String(), value.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MarginType string
+
+const (
+	// MarginPercent adds BasisPoints hundredths of a percent of the cost
+	MarginPercent MarginType = "percent"
+	// MarginFixed adds a fixed Amount to the cost
+	MarginFixed MarginType = "fixed"
+)
+
+// PriceTier is a set of margin rules applied to everyone with a role, or to a
+// single user. A user's own tier takes precedence over the tier of their role.
+type PriceTier struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Name        string         `gorm:"not null;uniqueIndex" json:"name"`
+	Description string         `gorm:"type:text" json:"description"`
+	Role        *Role          `gorm:"type:varchar(10);index" json:"role,omitempty"`
+	UserID      *uint          `gorm:"index" json:"user_id,omitempty"`
+	IsActive    bool           `gorm:"not null" json:"is_active"`
+	Rules       []MarginRule   `gorm:"foreignKey:TierID;constraint:OnDelete:CASCADE" json:"rules"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName specifies the table name for the PriceTier model
+func (PriceTier) TableName() string {
+	return "price_tiers"
+}
+
+// MarginRule is the markup a tier adds over supplier cost. A rule without a
+// category applies to every category that has no rule of its own.
+type MarginRule struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TierID      uint       `gorm:"not null;index" json:"tier_id"`
+	Category    string     `gorm:"index" json:"category"`
+	Type        MarginType `gorm:"type:varchar(10);not null" json:"type"`
+	BasisPoints int64      `json:"basis_points,omitempty"`
+	Amount      Money      `json:"amount"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the MarginRule model
+func (MarginRule) TableName() string {
+	return "margin_rules"
+}
+
+// Validate performs validation on price tier data
+func (t *PriceTier) Validate() error {
+	if t.Name == "" {
+		return ErrTierNameRequired
+	}
+	if (t.Role == nil) == (t.UserID == nil) {
+		return ErrTierTargetRequired
+	}
+
+	categories := make(map[string]bool, len(t.Rules))
+	for _, rule := range t.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if categories[rule.Category] {
+			return ErrDuplicateMarginRule
+		}
+		categories[rule.Category] = true
+	}
+	return nil
+}
+
+// Validate performs validation on margin rule data
+func (r *MarginRule) Validate() error {
+	switch r.Type {
+	case MarginPercent:
+		if r.BasisPoints < 0 {
+			return ErrInvalidMargin
+		}
+	case MarginFixed:
+		if r.Amount.Minor < 0 {
+			return ErrInvalidMargin
+		}
+	default:
+		return ErrInvalidMarginType
+	}
+	return nil
+}
+
+// Apply returns cost with the rule's markup added, rounded half up to the
+// nearest minor unit
+func (r *MarginRule) Apply(cost Money) Money {
+	switch r.Type {
+	case MarginPercent:
+		markup := (cost.Minor*r.BasisPoints + 5000) / 10000
+		return Money{Minor: cost.Minor + markup, Currency: cost.Currency}
+	case MarginFixed:
+		return Money{Minor: cost.Minor + r.Amount.Minor, Currency: cost.Currency}
+	}
+	return cost
+}
+
+// RuleFor returns the rule for a category, falling back to the tier-wide rule
+func (t *PriceTier) RuleFor(category string) *MarginRule {
+	var fallback *MarginRule
+	for i := range t.Rules {
+		switch t.Rules[i].Category {
+		case category:
+			return &t.Rules[i]
+		case "":
+			fallback = &t.Rules[i]
+		}
+	}
+	return fallback
+}
+
+// Custom errors for price tier validation
+var (
+	ErrTierNameRequired    = ValidationError{"tier name is required"}
+	ErrTierTargetRequired  = ValidationError{"tier must apply to either a role or a user"}
+	ErrDuplicateMarginRule = ValidationError{"only one margin rule per category is allowed"}
+	ErrInvalidMarginType   = ValidationError{"margin type must be percent or fixed"}
+	ErrInvalidMargin       = ValidationError{"margin must not be negative"}
+)
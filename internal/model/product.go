@@ -11,11 +11,24 @@ type Product struct {
 	ID          uint           `gorm:"primaryKey" json:"id"`
 	Name        string         `gorm:"not null" json:"name"`
 	Category    string         `gorm:"not null;index" json:"category"`
-	Price       float64        `gorm:"not null" json:"price"`
+	// GameID is the game the product tops up. Products synced from a
+	// supplier have none until an admin assigns one.
+	GameID      *uint          `gorm:"index" json:"game_id,omitempty"`
+	Game        *Game          `gorm:"foreignKey:GameID" json:"game,omitempty"`
+	Price       Money          `gorm:"not null" json:"price"`
+	// Cost is what the product costs us at the supplier. Tier prices are
+	// computed from it and it is never shown to customers.
+	Cost        Money          `gorm:"not null;default:0" json:"-"`
+	// MarginAlert is set when the price no longer covers cost plus the
+	// minimum margin, typically after the supplier raised its price
+	MarginAlert bool           `gorm:"not null;default:false;index" json:"-"`
 	Description string         `gorm:"type:text" json:"description"`
 	SKU         string         `gorm:"uniqueIndex" json:"sku"`
 	IsActive    bool          `gorm:"default:true" json:"is_active"`
 	Stock       int           `gorm:"default:0" json:"stock"`
+	// SupplierStock is the stock VIP Reseller reported at the last catalog
+	// sync. Syncs apply only its change to Stock, which keeps reservations.
+	SupplierStock *int        `json:"-"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
@@ -40,9 +53,12 @@ func (p *Product) Validate() error {
 	if p.Category == "" {
 		return ErrProductCategoryRequired
 	}
-	if p.Price <= 0 {
+	if !p.Price.IsPositive() {
 		return ErrInvalidProductPrice
 	}
+	if p.Cost.Minor < 0 {
+		return ErrInvalidProductCost
+	}
 	return nil
 }
 
@@ -51,6 +67,7 @@ var (
 	ErrProductNameRequired     = ValidationError{"product name is required"}
 	ErrProductCategoryRequired = ValidationError{"product category is required"}
 	ErrInvalidProductPrice     = ValidationError{"product price must be greater than 0"}
+	ErrInvalidProductCost      = ValidationError{"product cost must not be negative"}
 )
 
 // ValidationError represents a validation error
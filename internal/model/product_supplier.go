@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// ProductSupplier maps a product to the SKU it is sold under by one supplier.
+// Orders go to the active supplier with the lowest priority first.
+type ProductSupplier struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_product_supplier" json:"product_id"`
+	Supplier  string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_product_supplier" json:"supplier"`
+	SKU       string    `gorm:"not null" json:"sku"`
+	Priority  int       `gorm:"not null;default:0" json:"priority"`
+	IsActive  bool      `gorm:"not null" json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the ProductSupplier model
+func (ProductSupplier) TableName() string {
+	return "product_suppliers"
+}
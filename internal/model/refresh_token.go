@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// RefreshToken is a single-use token exchanged for a new access token. Every
+// exchange issues a successor in the same family, which is one login session.
+// Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	FamilyID  string    `gorm:"type:varchar(32);not null;index" json:"family_id"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	// UsedAt is set once the token was exchanged for its successor
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for the RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RevokedToken denylists access tokens before they expire. JTI is either the
+// ID of a single access token or a session ID, which revokes every access
+// token issued to that session.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:varchar(64)" json:"jti"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the RevokedToken model
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
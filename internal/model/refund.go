@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "pending"
+	RefundCompleted RefundStatus = "completed"
+	RefundFailed    RefundStatus = "failed"
+)
+
+// RefundMethod is where the money of a refund goes back to
+type RefundMethod string
+
+const (
+	// RefundToWallet credits the buyer's wallet
+	RefundToWallet RefundMethod = "wallet"
+	// RefundToPaymentProvider reverses the payment with the payment provider
+	RefundToPaymentProvider RefundMethod = "payment_provider"
+)
+
+// Refund is a full refund of a paid transaction. A transaction has at most
+// one refund; a failed refund is retried on the same record.
+type Refund struct {
+	ID                uint         `gorm:"primaryKey" json:"id"`
+	TransactionID     uint         `gorm:"not null;uniqueIndex" json:"transaction_id"`
+	Transaction       *Transaction `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	Invoice           string       `gorm:"not null;index" json:"invoice"`
+	Amount            Money        `gorm:"not null" json:"amount"`
+	Method            RefundMethod `gorm:"type:varchar(20);not null" json:"method"`
+	Status            RefundStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Reason            string       `gorm:"type:text" json:"reason,omitempty"`
+	ProviderReference string       `json:"provider_reference,omitempty"`
+	FailureReason     string       `gorm:"type:text" json:"failure_reason,omitempty"`
+	RequestedBy       *uint        `json:"requested_by,omitempty"`
+	CompletedAt       *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Refund model
+func (Refund) TableName() string {
+	return "refunds"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type ApplicationStatus string
+
+const (
+	ApplicationPending  ApplicationStatus = "pending"
+	ApplicationApproved ApplicationStatus = "approved"
+	ApplicationRejected ApplicationStatus = "rejected"
+)
+
+// ResellerApplication is a customer's request to become a reseller. A user
+// has at most one pending application; approving it makes them a reseller.
+type ResellerApplication struct {
+	ID           uint              `gorm:"primaryKey" json:"id"`
+	UserID       uint              `gorm:"not null;index;uniqueIndex:idx_reseller_applications_pending,where:status = 'pending'" json:"user_id"`
+	User         *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	BusinessName string            `gorm:"not null" json:"business_name"`
+	Phone        string            `gorm:"type:varchar(30);not null" json:"phone"`
+	Message      string            `gorm:"type:text" json:"message,omitempty"`
+	Status       ApplicationStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	ReviewedBy   *uint             `json:"reviewed_by,omitempty"`
+	ReviewNote   string            `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt   *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// TableName specifies the table name for the ResellerApplication model
+func (ResellerApplication) TableName() string {
+	return "reseller_applications"
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type SyncRunStatus string
+
+const (
+	// SyncRunning runs have not finished yet
+	SyncRunning SyncRunStatus = "running"
+	// SyncSucceeded runs applied every item of the supplier catalog
+	SyncSucceeded SyncRunStatus = "success"
+	// SyncPartial runs applied the catalog but skipped items that failed
+	SyncPartial SyncRunStatus = "partial"
+	// SyncFailed runs changed nothing
+	SyncFailed SyncRunStatus = "failed"
+)
+
+type SyncAction string
+
+const (
+	SyncCreated     SyncAction = "created"
+	SyncUpdated     SyncAction = "updated"
+	SyncDeactivated SyncAction = "deactivated"
+	SyncReactivated SyncAction = "reactivated"
+	SyncItemFailed  SyncAction = "failed"
+)
+
+// SyncItem is one product a sync changed or could not apply
+type SyncItem struct {
+	SKU    string     `json:"sku"`
+	Name   string     `json:"name,omitempty"`
+	Action SyncAction `json:"action"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// SyncItems is stored as a JSON array
+type SyncItems []SyncItem
+
+// Value implements driver.Valuer
+func (i SyncItems) Value() (driver.Value, error) {
+	if i == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(i)
+	return string(b), err
+}
+
+// Scan implements sql.Scanner
+func (i *SyncItems) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*i = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, i)
+	case string:
+		return json.Unmarshal([]byte(v), i)
+	}
+	return fmt.Errorf("cannot scan %T into SyncItems", value)
+}
+
+// SyncReport summarizes what a catalog sync changed. Unchanged products are
+// only counted; every other product is listed in Items.
+type SyncReport struct {
+	Created     int       `gorm:"not null;default:0" json:"created"`
+	Updated     int       `gorm:"not null;default:0" json:"updated"`
+	Unchanged   int       `gorm:"not null;default:0" json:"unchanged"`
+	Deactivated int       `gorm:"not null;default:0" json:"deactivated"`
+	Reactivated int       `gorm:"not null;default:0" json:"reactivated"`
+	Failed      int       `gorm:"not null;default:0" json:"failed"`
+	Items       SyncItems `gorm:"type:jsonb;not null;default:'[]'" json:"items"`
+}
+
+// Add records an item and counts it
+func (r *SyncReport) Add(item SyncItem) {
+	switch item.Action {
+	case SyncCreated:
+		r.Created++
+	case SyncUpdated:
+		r.Updated++
+	case SyncDeactivated:
+		r.Deactivated++
+	case SyncReactivated:
+		r.Reactivated++
+	case SyncItemFailed:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
+
+// SyncRun is the persisted history of a catalog sync and its report
+type SyncRun struct {
+	ID       uint          `gorm:"primaryKey" json:"id"`
+	Supplier string        `gorm:"type:varchar(50);not null" json:"supplier"`
+	Status   SyncRunStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Error    string        `gorm:"type:text" json:"error,omitempty"`
+	// TriggeredBy is the admin who started the run; scheduled runs have none
+	TriggeredBy *uint `json:"triggered_by,omitempty"`
+	// DryRun runs only compute the report and are never stored
+	DryRun     bool `gorm:"-" json:"dry_run,omitempty"`
+	SyncReport `gorm:"embedded"`
+	StartedAt  time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// TableName specifies the table name for the SyncRun model
+func (SyncRun) TableName() string {
+	return "sync_runs"
+}
@@ -9,30 +9,50 @@ import (
 type TransactionStatus string
 
 const (
-	StatusPending TransactionStatus = "pending"
-	StatusSuccess TransactionStatus = "success"
-	StatusFailed  TransactionStatus = "failed"
+	StatusAwaitingPayment TransactionStatus = "awaiting_payment"
+	StatusPaid            TransactionStatus = "paid"
+	StatusProcessing      TransactionStatus = "processing"
+	StatusSuccess         TransactionStatus = "success"
+	StatusFailed          TransactionStatus = "failed"
+	StatusRefunded        TransactionStatus = "refunded"
+	StatusExpired         TransactionStatus = "expired"
+	StatusCancelled       TransactionStatus = "cancelled"
 )
 
 // Transaction represents the transaction model for game top-up purchases
 type Transaction struct {
-	ID            uint              `gorm:"primaryKey" json:"id"`
-	UserID        *uint             `json:"user_id"`
-	User          *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	ProductID     uint              `json:"product_id"`
-	Product       Product           `gorm:"foreignKey:ProductID" json:"product"`
-	Method        string            `gorm:"not null" json:"method"`
-	Invoice       string            `gorm:"uniqueIndex;not null" json:"invoice"`
-	Status        TransactionStatus `gorm:"type:varchar(10);not null;index" json:"status"`
-	Amount        float64           `gorm:"not null" json:"amount"`
-	GameID        string            `gorm:"not null" json:"game_id"`
-	GameServer    string            `gorm:"not null" json:"game_server"`
-	PaymentProof  string            `gorm:"type:text" json:"payment_proof,omitempty"`
-	Notes         string            `gorm:"type:text" json:"notes,omitempty"`
-	VipOrderID    string            `gorm:"uniqueIndex" json:"vip_order_id,omitempty"`
-	CreatedAt     time.Time         `json:"created_at"`
-	UpdatedAt     time.Time         `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt    `gorm:"index" json:"-"`
+	ID               uint              `gorm:"primaryKey" json:"id"`
+	UserID           *uint             `json:"user_id"`
+	User             *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ProductID        uint              `json:"product_id"`
+	Product          Product           `gorm:"foreignKey:ProductID" json:"product"`
+	Method           string            `gorm:"not null" json:"method"`
+	Invoice          string            `gorm:"uniqueIndex;not null" json:"invoice"`
+	Status           TransactionStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Amount           Money             `gorm:"not null" json:"amount"`
+	GameID           string            `gorm:"not null" json:"game_id"`
+	GameServer       string            `gorm:"not null" json:"game_server"`
+	GameNickname     string            `json:"game_nickname,omitempty"`
+	PaymentProof     string            `gorm:"type:text" json:"payment_proof,omitempty"`
+	Notes            string            `gorm:"type:text" json:"notes,omitempty"`
+	Supplier         string            `gorm:"type:varchar(50);index:idx_transactions_supplier_order" json:"supplier,omitempty"`
+	SupplierSKU      string            `json:"supplier_sku,omitempty"`
+	SupplierOrderID  string            `gorm:"index:idx_transactions_supplier_order" json:"supplier_order_id,omitempty"`
+	PaymentProvider  string            `json:"payment_provider,omitempty"`
+	PaymentReference string            `gorm:"index" json:"payment_reference,omitempty"`
+	PaymentURL       string            `gorm:"type:text" json:"payment_url,omitempty"`
+	VANumber         string            `json:"va_number,omitempty"`
+	PaymentExpiresAt *time.Time        `json:"payment_expires_at,omitempty"`
+	PaidAt           *time.Time        `json:"paid_at,omitempty"`
+	StockReleased    bool              `gorm:"default:false" json:"-"`
+	// NeedsReview marks orders whose supplier call failed in a way that does
+	// not tell whether the order was placed; an admin has to find out
+	NeedsReview  bool           `gorm:"not null;default:false;index" json:"needs_review"`
+	SyncAttempts int            `gorm:"default:0" json:"-"`
+	NextSyncAt   *time.Time     `gorm:"index" json:"-"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // TableName specifies the table name for the Transaction model
@@ -43,7 +63,7 @@ func (Transaction) TableName() string {
 // BeforeCreate hook is called before creating a new transaction record
 func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 	if t.Status == "" {
-		t.Status = StatusPending
+		t.Status = StatusAwaitingPayment
 	}
 	return nil
 }
@@ -59,15 +79,19 @@ func (t *Transaction) Validate() error {
 	if t.GameID == "" {
 		return ErrGameIDRequired
 	}
-	if t.Amount <= 0 {
+	if !t.Amount.IsPositive() {
 		return ErrInvalidAmount
 	}
 	return nil
 }
 
-// IsComplete checks if the transaction is in a final state
+// IsComplete checks if the transaction is no longer being processed
 func (t *Transaction) IsComplete() bool {
-	return t.Status == StatusSuccess || t.Status == StatusFailed
+	switch t.Status {
+	case StatusSuccess, StatusFailed, StatusRefunded, StatusExpired, StatusCancelled:
+		return true
+	}
+	return false
 }
 
 // Custom errors for transaction validation
@@ -75,5 +99,5 @@ var (
 	ErrProductIDRequired     = ValidationError{"product ID is required"}
 	ErrPaymentMethodRequired = ValidationError{"payment method is required"}
 	ErrGameIDRequired        = ValidationError{"game ID is required"}
-	ErrInvalidAmount        = ValidationError{"amount must be greater than 0"}
+	ErrInvalidAmount         = ValidationError{"amount must be greater than 0"}
 )
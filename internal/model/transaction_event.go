@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type EventSource string
+
+const (
+	EventSourceCheckout     EventSource = "checkout"
+	EventSourcePayment      EventSource = "payment"
+	EventSourceSupplierSync EventSource = "supplier_sync"
+	EventSourceWebhook      EventSource = "webhook"
+	EventSourceAdmin        EventSource = "admin"
+	EventSourceSystem       EventSource = "system"
+)
+
+// TransactionEvent records a single status change of a transaction
+type TransactionEvent struct {
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	TransactionID uint              `gorm:"not null;index" json:"transaction_id"`
+	FromStatus    TransactionStatus `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus      TransactionStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	Source        EventSource       `gorm:"type:varchar(20);not null" json:"source"`
+	ActorID       *uint             `json:"actor_id,omitempty"`
+	Reason        string            `gorm:"type:text" json:"reason,omitempty"`
+	Payload       string            `gorm:"type:text" json:"payload,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TableName specifies the table name for the TransactionEvent model
+func (TransactionEvent) TableName() string {
+	return "transaction_events"
+}
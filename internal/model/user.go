@@ -14,12 +14,23 @@ const (
 	RoleReseller Role = "reseller"
 )
 
+type UserStatus string
+
+const (
+	UserActive UserStatus = "active"
+	// UserSuspended users cannot sign in and their tokens are rejected
+	UserSuspended UserStatus = "suspended"
+)
+
 // User represents the user model
 type User struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
 	Password  string         `gorm:"not null" json:"-"`
 	Role      Role          `gorm:"type:varchar(10);not null" json:"role"`
+	Status    UserStatus     `gorm:"type:varchar(20);not null;default:active;index" json:"status"`
+	// EmailVerifiedAt is set once the user proved they own their email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Wallet is a user's prepaid balance. Balance always equals the sum of the
+// ledger entries posted to the wallet's account.
+type Wallet struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID" json:"-"`
+	Balance   Money     `gorm:"not null;default:0" json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Wallet model
+func (Wallet) TableName() string {
+	return "wallets"
+}
+
+// Account is the ledger account of the wallet
+func (w *Wallet) Account() string {
+	return fmt.Sprintf("wallet:%d", w.ID)
+}
+
+// System ledger accounts on the other side of wallet movements
+const (
+	// AccountTopUps receives money paid in through the payment gateway
+	AccountTopUps = "system:topups"
+	// AccountSales receives purchases paid from a balance and pays refunds
+	AccountSales = "system:sales"
+	// AccountAdjustments balances manual corrections made by admins
+	AccountAdjustments = "system:adjustments"
+)
+
+type LedgerEntryType string
+
+const (
+	LedgerTopUp      LedgerEntryType = "topup"
+	LedgerPurchase   LedgerEntryType = "purchase"
+	LedgerRefund     LedgerEntryType = "refund"
+	LedgerAdjustment LedgerEntryType = "adjustment"
+)
+
+// LedgerEntry is one side of a double-entry transfer. Every transfer posts a
+// debit (negative amount) and a credit (positive amount) sharing a
+// TransferID, so the entries of a transfer always sum to zero.
+type LedgerEntry struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	TransferID  string          `gorm:"type:varchar(36);not null;index" json:"transfer_id"`
+	Account     string          `gorm:"type:varchar(50);not null;index" json:"account"`
+	WalletID    *uint           `gorm:"index" json:"wallet_id,omitempty"`
+	Type        LedgerEntryType `gorm:"type:varchar(20);not null" json:"type"`
+	Amount      Money           `gorm:"not null" json:"amount"`
+	Reference   string          `gorm:"index" json:"reference,omitempty"`
+	Description string          `gorm:"type:text" json:"description,omitempty"`
+	ActorID     *uint           `json:"actor_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// TableName specifies the table name for the LedgerEntry model
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+type TopUpStatus string
+
+const (
+	TopUpPending TopUpStatus = "pending"
+	TopUpPaid    TopUpStatus = "paid"
+	TopUpFailed  TopUpStatus = "failed"
+	TopUpExpired TopUpStatus = "expired"
+)
+
+// WalletTopUp is a deposit into a wallet paid through the payment gateway
+type WalletTopUp struct {
+	ID               uint        `gorm:"primaryKey" json:"id"`
+	UserID           uint        `gorm:"not null;index" json:"user_id"`
+	WalletID         uint        `gorm:"not null;index" json:"wallet_id"`
+	Invoice          string      `gorm:"uniqueIndex;not null" json:"invoice"`
+	Amount           Money       `gorm:"not null" json:"amount"`
+	Method           string      `gorm:"not null" json:"method"`
+	Status           TopUpStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	PaymentProvider  string      `json:"payment_provider,omitempty"`
+	PaymentReference string      `gorm:"index" json:"payment_reference,omitempty"`
+	PaymentURL       string      `gorm:"type:text" json:"payment_url,omitempty"`
+	VANumber         string      `json:"va_number,omitempty"`
+	PaymentExpiresAt *time.Time  `json:"payment_expires_at,omitempty"`
+	PaidAt           *time.Time  `json:"paid_at,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// TableName specifies the table name for the WalletTopUp model
+func (WalletTopUp) TableName() string {
+	return "wallet_top_ups"
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogQueryParams struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   *uint
+	Limit      int
+	Offset     int
+}
+
+type AuditLogRepository interface {
+	Create(log *model.AuditLog) error
+	FindAll(params AuditLogQueryParams) ([]model.AuditLog, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(log *model.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *auditLogRepository) FindAll(params AuditLogQueryParams) ([]model.AuditLog, error) {
+	var logs []model.AuditLog
+	query := r.db.Model(&model.AuditLog{})
+
+	if params.ActorID != nil {
+		query = query.Where("actor_id = ?", *params.ActorID)
+	}
+	if params.Action != "" {
+		query = query.Where("action = ?", params.Action)
+	}
+	if params.TargetType != "" {
+		query = query.Where("target_type = ?", params.TargetType)
+	}
+	if params.TargetID != nil {
+		query = query.Where("target_id = ?", *params.TargetID)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&logs).Error
+	return logs, err
+}
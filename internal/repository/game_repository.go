@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGameNotFound = errors.New("game not found")
+	ErrGameExists   = errors.New("game slug already taken")
+	ErrGameInUse    = errors.New("game still has products")
+)
+
+type GameRepository interface {
+	Create(game *model.Game) error
+	Update(game *model.Game) error
+	Delete(id uint) error
+	FindByID(id uint) (*model.Game, error)
+	FindBySlug(slug string) (*model.Game, error)
+	FindAll(activeOnly bool) ([]model.Game, error)
+}
+
+type gameRepository struct {
+	db *gorm.DB
+}
+
+func NewGameRepository(db *gorm.DB) GameRepository {
+	return &gameRepository{db: db}
+}
+
+func (r *gameRepository) Create(game *model.Game) error {
+	err := r.db.Create(game).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrGameExists
+	}
+	return err
+}
+
+func (r *gameRepository) Update(game *model.Game) error {
+	result := r.db.Save(game)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return ErrGameExists
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGameNotFound
+	}
+	return nil
+}
+
+func (r *gameRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.Game{}, id)
+	if errors.Is(result.Error, gorm.ErrForeignKeyViolated) {
+		return ErrGameInUse
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGameNotFound
+	}
+	return nil
+}
+
+func (r *gameRepository) FindByID(id uint) (*model.Game, error) {
+	var game model.Game
+	err := r.db.First(&game, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGameNotFound
+		}
+		return nil, err
+	}
+	return &game, nil
+}
+
+func (r *gameRepository) FindBySlug(slug string) (*model.Game, error) {
+	var game model.Game
+	err := r.db.Where("slug = ?", slug).First(&game).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrGameNotFound
+		}
+		return nil, err
+	}
+	return &game, nil
+}
+
+func (r *gameRepository) FindAll(activeOnly bool) ([]model.Game, error) {
+	var games []model.Game
+	query := r.db
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("sort_order ASC, name ASC").Find(&games).Error
+	return games, err
+}
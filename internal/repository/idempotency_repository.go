@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	ErrIdempotencyKeyExists   = errors.New("idempotency key already exists")
+)
+
+type IdempotencyRepository interface {
+	Create(key *model.IdempotencyKey) error
+	Find(scope, key string) (*model.IdempotencyKey, error)
+	Complete(id uint, status int, body string) error
+	Delete(id uint) error
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Create(key *model.IdempotencyKey) error {
+	err := r.db.Create(key).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrIdempotencyKeyExists
+	}
+	return err
+}
+
+func (r *idempotencyRepository) Find(scope, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := r.db.Where("scope = ? AND key = ?", scope, key).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIdempotencyKeyNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Complete(id uint, status int, body string) error {
+	result := r.db.Model(&model.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"response_status": status,
+		"response_body":   body,
+		"completed_at":    time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIdempotencyKeyNotFound
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(id uint) error {
+	return r.db.Delete(&model.IdempotencyKey{}, id).Error
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InvoiceRepository interface {
+	// Next increments the counter of scope, starting it at 1, and returns
+	// the new value
+	Next(scope string) (int64, error)
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) Next(scope string) (int64, error) {
+	// A single upsert is atomic, so concurrent callers never get the same value
+	var value int64
+	err := r.db.Raw(`
+		INSERT INTO invoice_counters (scope, value, updated_at) VALUES (?, 1, ?)
+		ON CONFLICT (scope) DO UPDATE SET value = invoice_counters.value + 1, updated_at = EXCLUDED.updated_at
+		RETURNING value`, scope, time.Now()).Scan(&value).Error
+	return value, err
+}
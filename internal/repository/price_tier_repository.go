@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPriceTierNotFound = errors.New("price tier not found")
+	ErrPriceTierExists   = errors.New("price tier already exists")
+)
+
+type PriceTierRepository interface {
+	Create(tier *model.PriceTier) error
+	Update(tier *model.PriceTier) error
+	Delete(id uint) error
+	FindByID(id uint) (*model.PriceTier, error)
+	FindAll() ([]model.PriceTier, error)
+	FindActiveForUser(userID uint) (*model.PriceTier, error)
+	FindActiveForRole(role model.Role) (*model.PriceTier, error)
+}
+
+type priceTierRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceTierRepository(db *gorm.DB) PriceTierRepository {
+	return &priceTierRepository{db: db}
+}
+
+func (r *priceTierRepository) Create(tier *model.PriceTier) error {
+	err := r.db.Create(tier).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrPriceTierExists
+	}
+	return err
+}
+
+// Update saves the tier and replaces all of its margin rules
+func (r *priceTierRepository) Update(tier *model.PriceTier) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Rules").Save(tier)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPriceTierNotFound
+		}
+
+		if err := tx.Where("tier_id = ?", tier.ID).Delete(&model.MarginRule{}).Error; err != nil {
+			return err
+		}
+		if len(tier.Rules) == 0 {
+			return nil
+		}
+
+		for i := range tier.Rules {
+			tier.Rules[i].ID = 0
+			tier.Rules[i].TierID = tier.ID
+		}
+		return tx.Create(&tier.Rules).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrPriceTierExists
+	}
+	return err
+}
+
+func (r *priceTierRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.PriceTier{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPriceTierNotFound
+		}
+		return tx.Where("tier_id = ?", id).Delete(&model.MarginRule{}).Error
+	})
+}
+
+func (r *priceTierRepository) FindByID(id uint) (*model.PriceTier, error) {
+	var tier model.PriceTier
+	err := r.db.Preload("Rules").First(&tier, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPriceTierNotFound
+		}
+		return nil, err
+	}
+	return &tier, nil
+}
+
+func (r *priceTierRepository) FindAll() ([]model.PriceTier, error) {
+	var tiers []model.PriceTier
+	err := r.db.Preload("Rules").Order("name ASC").Find(&tiers).Error
+	return tiers, err
+}
+
+func (r *priceTierRepository) FindActiveForUser(userID uint) (*model.PriceTier, error) {
+	return r.findActive(r.db.Where("user_id = ?", userID))
+}
+
+func (r *priceTierRepository) FindActiveForRole(role model.Role) (*model.PriceTier, error) {
+	return r.findActive(r.db.Where("role = ?", role))
+}
+
+func (r *priceTierRepository) findActive(query *gorm.DB) (*model.PriceTier, error) {
+	var tier model.PriceTier
+	err := query.Preload("Rules").Where("is_active = true").
+		Order("updated_at DESC").First(&tier).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPriceTierNotFound
+		}
+		return nil, err
+	}
+	return &tier, nil
+}
@@ -8,29 +8,48 @@ import (
 )
 
 var (
-	ErrProductNotFound = errors.New("product not found")
-	ErrSKUTaken       = errors.New("product SKU already taken")
+	ErrProductNotFound   = errors.New("product not found")
+	ErrSKUTaken          = errors.New("product SKU already taken")
+	ErrInsufficientStock = errors.New("insufficient product stock")
 )
 
 type ProductRepository interface {
 	Create(product *model.Product) error
 	Update(product *model.Product) error
+	// UpdateCatalog saves the fields a catalog sync manages, leaving stock
+	// to SyncSupplierStock
+	UpdateCatalog(product *model.Product) error
 	Delete(id uint) error
 	FindByID(id uint) (*model.Product, error)
 	FindBySKU(sku string) (*model.Product, error)
 	FindAll(params ProductQueryParams) ([]model.Product, error)
 	FindByCategory(category string) ([]model.Product, error)
+	// FindAllWithSKU returns every product that has a SKU, including
+	// deleted ones, whose SKUs stay taken
+	FindAllWithSKU() ([]model.Product, error)
+	// FindMappedProductIDs returns the IDs of products that have supplier
+	// mappings
+	FindMappedProductIDs() ([]uint, error)
 	UpdateStock(id uint, quantity int) error
+	ReserveStock(id uint, quantity int) error
+	ReleaseStock(id uint, quantity int) error
+	// SyncSupplierStock records the stock reported by the supplier and
+	// applies its change since the last sync to the product's stock
+	SyncSupplierStock(id uint, supplierStock int) error
+	FindSuppliers(productID uint, activeOnly bool) ([]model.ProductSupplier, error)
+	ReplaceSuppliers(productID uint, suppliers []model.ProductSupplier) error
 }
 
 type ProductQueryParams struct {
-	Category string
-	IsActive *bool
-	Search   string
-	SortBy   string
-	SortDesc bool
-	Limit    int
-	Offset   int
+	Category    string
+	GameID      *uint
+	IsActive    *bool
+	MarginAlert *bool
+	Search      string
+	SortBy      string
+	SortDesc    bool
+	Limit       int
+	Offset      int
 }
 
 type productRepository struct {
@@ -53,11 +72,33 @@ func (r *productRepository) Create(product *model.Product) error {
 		}
 	}
 
-	return r.db.Create(product).Error
+	err := r.db.Omit("Game").Create(product).Error
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		return ErrGameNotFound
+	}
+	return err
 }
 
 func (r *productRepository) Update(product *model.Product) error {
-	result := r.db.Save(product)
+	// The game is assigned through GameID; saving a loaded Game would
+	// overwrite it
+	result := r.db.Omit("Game").Save(product)
+	if errors.Is(result.Error, gorm.ErrForeignKeyViolated) {
+		return ErrGameNotFound
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+func (r *productRepository) UpdateCatalog(product *model.Product) error {
+	result := r.db.Model(product).
+		Select("name", "cost", "description", "margin_alert", "is_active").
+		Updates(product)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -80,7 +121,7 @@ func (r *productRepository) Delete(id uint) error {
 
 func (r *productRepository) FindByID(id uint) (*model.Product, error) {
 	var product model.Product
-	err := r.db.First(&product, id).Error
+	err := r.db.Preload("Game").First(&product, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrProductNotFound
@@ -104,15 +145,21 @@ func (r *productRepository) FindBySKU(sku string) (*model.Product, error) {
 
 func (r *productRepository) FindAll(params ProductQueryParams) ([]model.Product, error) {
 	var products []model.Product
-	query := r.db
+	query := r.db.Preload("Game")
 
 	// Apply filters
 	if params.Category != "" {
 		query = query.Where("category = ?", params.Category)
 	}
+	if params.GameID != nil {
+		query = query.Where("game_id = ?", *params.GameID)
+	}
 	if params.IsActive != nil {
 		query = query.Where("is_active = ?", *params.IsActive)
 	}
+	if params.MarginAlert != nil {
+		query = query.Where("margin_alert = ?", *params.MarginAlert)
+	}
 	if params.Search != "" {
 		query = query.Where("name ILIKE ? OR description ILIKE ?", "%"+params.Search+"%", "%"+params.Search+"%")
 	}
@@ -144,6 +191,18 @@ func (r *productRepository) FindByCategory(category string) ([]model.Product, er
 	return products, err
 }
 
+func (r *productRepository) FindAllWithSKU() ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.Unscoped().Where("sku <> ''").Find(&products).Error
+	return products, err
+}
+
+func (r *productRepository) FindMappedProductIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.ProductSupplier{}).Distinct("product_id").Pluck("product_id", &ids).Error
+	return ids, err
+}
+
 func (r *productRepository) UpdateStock(id uint, quantity int) error {
 	result := r.db.Model(&model.Product{}).Where("id = ?", id).
 		Update("stock", gorm.Expr("stock + ?", quantity))
@@ -155,3 +214,74 @@ func (r *productRepository) UpdateStock(id uint, quantity int) error {
 	}
 	return nil
 }
+
+// ReserveStock takes quantity units out of stock in a single conditional
+// update, so concurrent buyers can never take stock below zero
+func (r *productRepository) ReserveStock(id uint, quantity int) error {
+	result := r.db.Model(&model.Product{}).Where("id = ? AND stock >= ?", id, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByID(id); err != nil {
+			return err
+		}
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// ReleaseStock puts reserved units back into stock. Products deleted since
+// the reservation get them back as well, so that restoring one restores its
+// stock; a product that no longer exists at all has nothing to release.
+func (r *productRepository) ReleaseStock(id uint, quantity int) error {
+	return r.db.Unscoped().Model(&model.Product{}).Where("id = ?", id).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// SyncSupplierStock applies the change in one statement, so units reserved
+// since the product was read are kept. Products never synced before take the
+// reported stock as it is.
+func (r *productRepository) SyncSupplierStock(id uint, supplierStock int) error {
+	result := r.db.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"stock":          gorm.Expr("GREATEST(stock + ? - COALESCE(supplier_stock, stock), 0)", supplierStock),
+		"supplier_stock": supplierStock,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// FindSuppliers returns the supplier mappings of a product in order of preference
+func (r *productRepository) FindSuppliers(productID uint, activeOnly bool) ([]model.ProductSupplier, error) {
+	var suppliers []model.ProductSupplier
+	query := r.db.Where("product_id = ?", productID)
+	if activeOnly {
+		query = query.Where("is_active = true")
+	}
+	err := query.Order("priority ASC, id ASC").Find(&suppliers).Error
+	return suppliers, err
+}
+
+// ReplaceSuppliers swaps all supplier mappings of a product for the given ones
+func (r *productRepository) ReplaceSuppliers(productID uint, suppliers []model.ProductSupplier) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductSupplier{}).Error; err != nil {
+			return err
+		}
+		if len(suppliers) == 0 {
+			return nil
+		}
+
+		for i := range suppliers {
+			suppliers[i].ID = 0
+			suppliers[i].ProductID = productID
+		}
+		return tx.Create(&suppliers).Error
+	})
+}
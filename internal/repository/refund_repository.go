@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefundNotFound = errors.New("refund not found")
+	ErrRefundExists   = errors.New("transaction already has a refund")
+)
+
+type RefundQueryParams struct {
+	Status *model.RefundStatus
+	Limit  int
+	Offset int
+}
+
+type RefundRepository interface {
+	Create(refund *model.Refund) error
+	// Update saves every column except status, which only changes through
+	// UpdateStatus
+	Update(refund *model.Refund) error
+	// UpdateStatus is a compare-and-set on the refund's status
+	UpdateStatus(id uint, from, to model.RefundStatus) error
+	FindByID(id uint) (*model.Refund, error)
+	FindByTransactionID(transactionID uint) (*model.Refund, error)
+	FindAll(params RefundQueryParams) ([]model.Refund, error)
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Create(refund *model.Refund) error {
+	err := r.db.Create(refund).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrRefundExists
+	}
+	return err
+}
+
+func (r *refundRepository) Update(refund *model.Refund) error {
+	result := r.db.Omit("status", "Transaction").Save(refund)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefundNotFound
+	}
+	return nil
+}
+
+func (r *refundRepository) UpdateStatus(id uint, from, to model.RefundStatus) error {
+	result := r.db.Model(&model.Refund{}).Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+func (r *refundRepository) FindByID(id uint) (*model.Refund, error) {
+	var refund model.Refund
+	err := r.db.Preload("Transaction").First(&refund, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) FindByTransactionID(transactionID uint) (*model.Refund, error) {
+	var refund model.Refund
+	err := r.db.Where("transaction_id = ?", transactionID).First(&refund).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) FindAll(params RefundQueryParams) ([]model.Refund, error) {
+	var refunds []model.Refund
+	query := r.db.Model(&model.Refund{})
+
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&refunds).Error
+	return refunds, err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrApplicationNotFound = errors.New("reseller application not found")
+	ErrApplicationPending  = errors.New("user already has a pending reseller application")
+)
+
+type ResellerApplicationQueryParams struct {
+	Status *model.ApplicationStatus
+	Limit  int
+	Offset int
+}
+
+type ResellerApplicationRepository interface {
+	Create(application *model.ResellerApplication) error
+	// Update saves every column except status, which only changes through
+	// UpdateStatus
+	Update(application *model.ResellerApplication) error
+	// UpdateStatus is a compare-and-set on the application's status
+	UpdateStatus(id uint, from, to model.ApplicationStatus) error
+	FindByID(id uint) (*model.ResellerApplication, error)
+	// FindLatestByUserID returns the user's most recent application
+	FindLatestByUserID(userID uint) (*model.ResellerApplication, error)
+	FindAll(params ResellerApplicationQueryParams) ([]model.ResellerApplication, error)
+}
+
+type resellerApplicationRepository struct {
+	db *gorm.DB
+}
+
+func NewResellerApplicationRepository(db *gorm.DB) ResellerApplicationRepository {
+	return &resellerApplicationRepository{db: db}
+}
+
+func (r *resellerApplicationRepository) Create(application *model.ResellerApplication) error {
+	err := r.db.Omit("User").Create(application).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrApplicationPending
+	}
+	return err
+}
+
+func (r *resellerApplicationRepository) Update(application *model.ResellerApplication) error {
+	result := r.db.Omit("status", "User").Save(application)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrApplicationNotFound
+	}
+	return nil
+}
+
+func (r *resellerApplicationRepository) UpdateStatus(id uint, from, to model.ApplicationStatus) error {
+	result := r.db.Model(&model.ResellerApplication{}).Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+func (r *resellerApplicationRepository) FindByID(id uint) (*model.ResellerApplication, error) {
+	var application model.ResellerApplication
+	err := r.db.Preload("User").First(&application, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	return &application, nil
+}
+
+func (r *resellerApplicationRepository) FindLatestByUserID(userID uint) (*model.ResellerApplication, error) {
+	var application model.ResellerApplication
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&application).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApplicationNotFound
+		}
+		return nil, err
+	}
+	return &application, nil
+}
+
+func (r *resellerApplicationRepository) FindAll(params ResellerApplicationQueryParams) ([]model.ResellerApplication, error) {
+	var applications []model.ResellerApplication
+	query := r.db.Preload("User")
+
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	err := query.Order("created_at DESC").Find(&applications).Error
+	return applications, err
+}
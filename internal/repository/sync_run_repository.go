@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrSyncRunNotFound = errors.New("sync run not found")
+
+type SyncRunQueryParams struct {
+	Status *model.SyncRunStatus
+	Limit  int
+	Offset int
+}
+
+type SyncRunRepository interface {
+	Create(run *model.SyncRun) error
+	Update(run *model.SyncRun) error
+	FindAll(params SyncRunQueryParams) ([]model.SyncRun, error)
+}
+
+type syncRunRepository struct {
+	db *gorm.DB
+}
+
+func NewSyncRunRepository(db *gorm.DB) SyncRunRepository {
+	return &syncRunRepository{db: db}
+}
+
+func (r *syncRunRepository) Create(run *model.SyncRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *syncRunRepository) Update(run *model.SyncRun) error {
+	result := r.db.Save(run)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSyncRunNotFound
+	}
+	return nil
+}
+
+func (r *syncRunRepository) FindAll(params SyncRunQueryParams) ([]model.SyncRun, error) {
+	var runs []model.SyncRun
+	query := r.db.Model(&model.SyncRun{})
+
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	err := query.Order("started_at DESC").Find(&runs).Error
+	return runs, err
+}
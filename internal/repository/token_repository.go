@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(token *model.RefreshToken) error
+	FindRefreshToken(tokenHash string) (*model.RefreshToken, error)
+	// MarkRefreshTokenUsed is a compare-and-set: it fails with
+	// ErrRefreshTokenUsed when the token was used or revoked before
+	MarkRefreshTokenUsed(id uint, usedAt time.Time) error
+	// RevokeFamily revokes every refresh token of a session
+	RevokeFamily(familyID string, revokedAt time.Time) error
+	// RevokeUserTokens revokes every refresh token of a user and returns the
+	// sessions that were still alive
+	RevokeUserTokens(userID uint, revokedAt time.Time) ([]string, error)
+	// Deny adds entries to the access token denylist. Entries past their
+	// expiry are dropped on the way.
+	Deny(entries []model.RevokedToken) error
+	// IsDenied reports whether any of the given IDs is denylisted
+	IsDenied(ids ...string) (bool, error)
+}
+
+type tokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) TokenRepository {
+	return &tokenRepository{db: db}
+}
+
+func (r *tokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *tokenRepository) FindRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) MarkRefreshTokenUsed(id uint, usedAt time.Time) error {
+	result := r.db.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefreshTokenUsed
+	}
+	return nil
+}
+
+func (r *tokenRepository) RevokeFamily(familyID string, revokedAt time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *tokenRepository) RevokeUserTokens(userID uint, revokedAt time.Time) ([]string, error) {
+	var families []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, revokedAt).
+			Distinct("family_id").Pluck("family_id", &families).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", revokedAt).Error
+	})
+	return families, err
+}
+
+func (r *tokenRepository) Deny(entries []model.RevokedToken) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", time.Now()).Delete(&model.RevokedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+	})
+}
+
+func (r *tokenRepository) IsDenied(ids ...string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.RevokedToken{}).
+		Where("jti IN ? AND expires_at > ?", ids, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 	"topup-game/internal/model"
 
 	"gorm.io/gorm"
@@ -9,7 +10,8 @@ import (
 
 var (
 	ErrTransactionNotFound = errors.New("transaction not found")
-	ErrInvoiceExists      = errors.New("invoice number already exists")
+	ErrInvoiceExists       = errors.New("invoice number already exists")
+	ErrStatusConflict      = errors.New("transaction status was changed concurrently")
 )
 
 type TransactionRepository interface {
@@ -17,22 +19,30 @@ type TransactionRepository interface {
 	Update(transaction *model.Transaction) error
 	FindByID(id uint) (*model.Transaction, error)
 	FindByInvoice(invoice string) (*model.Transaction, error)
-	FindByVipOrderID(orderID string) (*model.Transaction, error)
+	FindBySupplierOrderID(supplier, orderID string) (*model.Transaction, error)
 	FindAll(params TransactionQueryParams) ([]model.Transaction, error)
 	FindByUserID(userID uint) ([]model.Transaction, error)
-	UpdateStatus(id uint, status model.TransactionStatus) error
+	UpdateStatus(id uint, from, to model.TransactionStatus, event *model.TransactionEvent) error
+	FindEvents(transactionID uint) ([]model.TransactionEvent, error)
+	FindDueForSync(now time.Time, limit int) ([]model.Transaction, error)
+	FindOverduePayments(now time.Time, limit int) ([]model.Transaction, error)
+	// FindPaidBefore returns paid transactions whose order has not been
+	// placed, paid before the given time
+	FindPaidBefore(before time.Time, limit int) ([]model.Transaction, error)
+	ScheduleSync(id uint, attempts int, nextSyncAt time.Time) error
 }
 
 type TransactionQueryParams struct {
-	Status    *model.TransactionStatus
-	Method    string
-	StartDate string
-	EndDate   string
-	Search    string
-	SortBy    string
-	SortDesc  bool
-	Limit     int
-	Offset    int
+	Status      *model.TransactionStatus
+	NeedsReview *bool
+	Method      string
+	StartDate   string
+	EndDate     string
+	Search      string
+	SortBy      string
+	SortDesc    bool
+	Limit       int
+	Offset      int
 }
 
 type transactionRepository struct {
@@ -53,11 +63,20 @@ func (r *transactionRepository) Create(transaction *model.Transaction) error {
 		return ErrInvoiceExists
 	}
 
-	return r.db.Create(transaction).Error
+	// Insert in a savepoint, so that inside a unit of work a duplicate
+	// invoice does not abort the whole transaction and can be retried
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(transaction).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrInvoiceExists
+	}
+	return err
 }
 
+// Update saves every column except status, which only changes through UpdateStatus
 func (r *transactionRepository) Update(transaction *model.Transaction) error {
-	result := r.db.Save(transaction)
+	result := r.db.Omit("status").Save(transaction)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -92,10 +111,10 @@ func (r *transactionRepository) FindByInvoice(invoice string) (*model.Transactio
 	return &transaction, nil
 }
 
-func (r *transactionRepository) FindByVipOrderID(orderID string) (*model.Transaction, error) {
+func (r *transactionRepository) FindBySupplierOrderID(supplier, orderID string) (*model.Transaction, error) {
 	var transaction model.Transaction
 	err := r.db.Preload("Product").Preload("User").
-		Where("vip_order_id = ?", orderID).First(&transaction).Error
+		Where("supplier = ? AND supplier_order_id = ?", supplier, orderID).First(&transaction).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrTransactionNotFound
@@ -113,6 +132,9 @@ func (r *transactionRepository) FindAll(params TransactionQueryParams) ([]model.
 	if params.Status != nil {
 		query = query.Where("status = ?", *params.Status)
 	}
+	if params.NeedsReview != nil {
+		query = query.Where("needs_review = ?", *params.NeedsReview)
+	}
 	if params.Method != "" {
 		query = query.Where("method = ?", params.Method)
 	}
@@ -157,9 +179,76 @@ func (r *transactionRepository) FindByUserID(userID uint) ([]model.Transaction,
 	return transactions, err
 }
 
-func (r *transactionRepository) UpdateStatus(id uint, status model.TransactionStatus) error {
-	result := r.db.Model(&model.Transaction{}).Where("id = ?", id).
-		Update("status", status)
+// UpdateStatus moves a transaction to a new status only if it is still in the
+// expected previous status, so concurrent updates cannot overwrite each other.
+// The event is recorded in the same database transaction as the change.
+func (r *transactionRepository) UpdateStatus(id uint, from, to model.TransactionStatus, event *model.TransactionEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Transaction{}).Where("id = ? AND status = ?", id, from).
+			Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&model.Transaction{}).Where("id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrTransactionNotFound
+			}
+			return ErrStatusConflict
+		}
+
+		event.TransactionID = id
+		event.FromStatus = from
+		event.ToStatus = to
+		return tx.Create(event).Error
+	})
+}
+
+func (r *transactionRepository) FindEvents(transactionID uint) ([]model.TransactionEvent, error) {
+	var events []model.TransactionEvent
+	err := r.db.Where("transaction_id = ?", transactionID).
+		Order("created_at ASC, id ASC").Find(&events).Error
+	return events, err
+}
+
+// FindDueForSync returns orders placed with a supplier that are still
+// processing and whose next status check is due
+func (r *transactionRepository) FindDueForSync(now time.Time, limit int) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := r.db.Preload("Product").
+		Where("status = ? AND supplier_order_id <> ''", model.StatusProcessing).
+		Where("next_sync_at IS NULL OR next_sync_at <= ?", now).
+		Order("next_sync_at ASC NULLS FIRST").
+		Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+// FindOverduePayments returns transactions whose payment window has passed
+func (r *transactionRepository) FindOverduePayments(now time.Time, limit int) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := r.db.Where("status = ? AND payment_expires_at < ?", model.StatusAwaitingPayment, now).
+		Order("payment_expires_at ASC").
+		Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *transactionRepository) FindPaidBefore(before time.Time, limit int) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := r.db.Preload("Product").
+		Where("status = ? AND paid_at < ?", model.StatusPaid, before).
+		Order("paid_at ASC").
+		Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *transactionRepository) ScheduleSync(id uint, attempts int, nextSyncAt time.Time) error {
+	result := r.db.Model(&model.Transaction{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"sync_attempts": attempts,
+		"next_sync_at":  nextSyncAt,
+	})
 	if result.Error != nil {
 		return result.Error
 	}
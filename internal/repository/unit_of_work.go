@@ -0,0 +1,45 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Repositories groups repositories that share the same database handle
+type Repositories struct {
+	Users        UserRepository
+	Products     ProductRepository
+	Transactions TransactionRepository
+	Wallets      WalletRepository
+	Refunds      RefundRepository
+	Applications ResellerApplicationRepository
+	AuditLogs    AuditLogRepository
+	UserTokens   UserTokenRepository
+}
+
+// UnitOfWork runs a group of repository operations atomically
+type UnitOfWork interface {
+	// Do runs fn inside a database transaction. The transaction is committed
+	// when fn returns nil and rolled back otherwise.
+	Do(fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(fn func(repos Repositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Users:        NewUserRepository(tx),
+			Products:     NewProductRepository(tx),
+			Transactions: NewTransactionRepository(tx),
+			Wallets:      NewWalletRepository(tx),
+			Refunds:      NewRefundRepository(tx),
+			Applications: NewResellerApplicationRepository(tx),
+			AuditLogs:    NewAuditLogRepository(tx),
+			UserTokens:   NewUserTokenRepository(tx),
+		})
+	})
+}
@@ -18,9 +18,19 @@ type UserRepository interface {
 	FindByID(id uint) (*model.User, error)
 	FindByEmail(email string) (*model.User, error)
 	FindByRole(role model.Role) ([]model.User, error)
+	FindAll(params UserQueryParams) ([]model.User, error)
 	Delete(id uint) error
 }
 
+type UserQueryParams struct {
+	Role   *model.Role
+	Status *model.UserStatus
+	// Search matches part of the email address
+	Search string
+	Limit  int
+	Offset int
+}
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -39,7 +49,12 @@ func (r *userRepository) Create(user *model.User) error {
 		return ErrEmailTaken
 	}
 
-	return r.db.Create(user).Error
+	// Deleted users keep their email taken
+	err := r.db.Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrEmailTaken
+	}
+	return err
 }
 
 func (r *userRepository) Update(user *model.User) error {
@@ -86,6 +101,30 @@ func (r *userRepository) FindByRole(role model.Role) ([]model.User, error) {
 	return users, nil
 }
 
+func (r *userRepository) FindAll(params UserQueryParams) ([]model.User, error) {
+	var users []model.User
+	query := r.db.Model(&model.User{})
+
+	if params.Role != nil {
+		query = query.Where("role = ?", *params.Role)
+	}
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.Search != "" {
+		query = query.Where("email ILIKE ?", "%"+params.Search+"%")
+	}
+	if params.Limit > 0 {
+		query = query.Limit(params.Limit)
+	}
+	if params.Offset > 0 {
+		query = query.Offset(params.Offset)
+	}
+
+	err := query.Order("id ASC").Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) Delete(id uint) error {
 	result := r.db.Delete(&model.User{}, id)
 	if result.Error != nil {
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"time"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+)
+
+var ErrUserTokenInvalid = errors.New("token is invalid, used or expired")
+
+type UserTokenRepository interface {
+	Create(token *model.UserToken) error
+	// Consume uses up an unexpired token of the given purpose. Tokens can be
+	// consumed once; later attempts fail with ErrUserTokenInvalid.
+	Consume(tokenHash string, purpose model.UserTokenPurpose, now time.Time) (*model.UserToken, error)
+	// Invalidate uses up every outstanding token of a user for a purpose
+	Invalidate(userID uint, purpose model.UserTokenPurpose, now time.Time) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(token *model.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *userTokenRepository) Consume(tokenHash string, purpose model.UserTokenPurpose, now time.Time) (*model.UserToken, error) {
+	var token model.UserToken
+	err := r.db.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserTokenInvalid
+		}
+		return nil, err
+	}
+
+	// The conditions make concurrent attempts with the same token race for
+	// a single row update
+	result := r.db.Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrUserTokenInvalid
+	}
+
+	token.UsedAt = &now
+	return &token, nil
+}
+
+func (r *userTokenRepository) Invalidate(userID uint, purpose model.UserTokenPurpose, now time.Time) error {
+	return r.db.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
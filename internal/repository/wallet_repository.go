@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"topup-game/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWalletNotFound    = errors.New("wallet not found")
+	ErrInsufficientFunds = errors.New("insufficient wallet balance")
+	ErrTopUpNotFound     = errors.New("top-up not found")
+)
+
+type WalletRepository interface {
+	FindByUserID(userID uint) (*model.Wallet, error)
+	FindOrCreate(userID uint) (*model.Wallet, error)
+	// AddBalance changes a wallet's balance by amount. Negative amounts fail
+	// with ErrInsufficientFunds rather than taking the balance below zero.
+	AddBalance(walletID uint, amount model.Money) error
+	CreateEntries(entries []model.LedgerEntry) error
+	FindEntries(walletID uint, limit, offset int) ([]model.LedgerEntry, error)
+	CreateTopUp(topUp *model.WalletTopUp) error
+	UpdateTopUp(topUp *model.WalletTopUp) error
+	FindTopUpByInvoice(invoice string) (*model.WalletTopUp, error)
+	FindTopUps(userID uint) ([]model.WalletTopUp, error)
+	// UpdateTopUpStatus is a compare-and-set on the top-up's status
+	UpdateTopUpStatus(id uint, from, to model.TopUpStatus) error
+}
+
+type walletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &walletRepository{db: db}
+}
+
+func (r *walletRepository) FindByUserID(userID uint) (*model.Wallet, error) {
+	var wallet model.Wallet
+	err := r.db.Where("user_id = ?", userID).First(&wallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// FindOrCreate returns the user's wallet, opening an empty one if needed
+func (r *walletRepository) FindOrCreate(userID uint) (*model.Wallet, error) {
+	wallet := &model.Wallet{UserID: userID}
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.FindByUserID(userID)
+}
+
+func (r *walletRepository) AddBalance(walletID uint, amount model.Money) error {
+	query := r.db.Model(&model.Wallet{}).Where("id = ?", walletID)
+	if amount.Minor < 0 {
+		query = query.Where("balance >= ?", -amount.Minor)
+	}
+
+	result := query.Update("balance", gorm.Expr("balance + ?", amount.Minor))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := r.db.Model(&model.Wallet{}).Where("id = ?", walletID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrWalletNotFound
+		}
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (r *walletRepository) CreateEntries(entries []model.LedgerEntry) error {
+	return r.db.Create(&entries).Error
+}
+
+func (r *walletRepository) FindEntries(walletID uint, limit, offset int) ([]model.LedgerEntry, error) {
+	var entries []model.LedgerEntry
+	query := r.db.Where("wallet_id = ?", walletID).Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+func (r *walletRepository) CreateTopUp(topUp *model.WalletTopUp) error {
+	err := r.db.Create(topUp).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrInvoiceExists
+	}
+	return err
+}
+
+// UpdateTopUp saves every column except status, which only changes through
+// UpdateTopUpStatus
+func (r *walletRepository) UpdateTopUp(topUp *model.WalletTopUp) error {
+	result := r.db.Omit("status").Save(topUp)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTopUpNotFound
+	}
+	return nil
+}
+
+func (r *walletRepository) FindTopUpByInvoice(invoice string) (*model.WalletTopUp, error) {
+	var topUp model.WalletTopUp
+	err := r.db.Where("invoice = ?", invoice).First(&topUp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTopUpNotFound
+		}
+		return nil, err
+	}
+	return &topUp, nil
+}
+
+func (r *walletRepository) FindTopUps(userID uint) ([]model.WalletTopUp, error) {
+	var topUps []model.WalletTopUp
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&topUps).Error
+	return topUps, err
+}
+
+func (r *walletRepository) UpdateTopUpStatus(id uint, from, to model.TopUpStatus) error {
+	result := r.db.Model(&model.WalletTopUp{}).Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+	return nil
+}
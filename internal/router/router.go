@@ -8,12 +8,29 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Dependencies holds everything the HTTP handlers are built from
+type Dependencies struct {
+	UserService        service.UserService
+	ProductService     service.ProductService
+	TransactionService service.TransactionService
+	PaymentGateway     service.PaymentGateway
+	IdempotencyService service.IdempotencyService
+	VIPReseller        service.VIPResellerService
+	PricingService     service.PricingService
+	WalletService      service.WalletService
+	RefundService      service.RefundService
+	AccountValidator   service.GameAccountValidator
+	GameService        service.GameService
+	ApplicationService service.ResellerApplicationService
+	UserAdminService   service.UserAdminService
+	AccountService     service.AccountService
+
+	// WebhookAllowedIPs restricts who may call the VIP Reseller webhook
+	WebhookAllowedIPs []string
+}
+
 // SetupRouter configures and returns the Gin router
-func SetupRouter(
-	userService service.UserService,
-	productService service.ProductService,
-	transactionService service.TransactionService,
-) *gin.Engine {
+func SetupRouter(deps Dependencies) *gin.Engine {
 	router := gin.New()
 
 	// Use logger and recovery middleware
@@ -22,14 +39,25 @@ func SetupRouter(
 	router.Use(middleware.RecoveryLogger())
 
 	// Create handlers
-	userHandler := handler.NewUserHandler(userService)
-	productHandler := handler.NewProductHandler(productService)
-	transactionHandler := handler.NewTransactionHandler(transactionService)
+	userHandler := handler.NewUserHandler(deps.UserService, deps.AccountService)
+	accountHandler := handler.NewAccountHandler(deps.AccountService)
+	productHandler := handler.NewProductHandler(deps.ProductService)
+	transactionHandler := handler.NewTransactionHandler(deps.TransactionService)
+	paymentHandler := handler.NewPaymentHandler(deps.PaymentGateway, deps.TransactionService, deps.WalletService)
+	webhookHandler := handler.NewWebhookHandler(deps.VIPReseller, deps.TransactionService)
+	pricingHandler := handler.NewPricingHandler(deps.PricingService)
+	walletHandler := handler.NewWalletHandler(deps.WalletService)
+	refundHandler := handler.NewRefundHandler(deps.RefundService)
+	gameHandler := handler.NewGameHandler(deps.GameService, deps.AccountValidator)
+	applicationHandler := handler.NewResellerApplicationHandler(deps.ApplicationService)
+	adminUserHandler := handler.NewAdminUserHandler(deps.UserAdminService)
 
 	// Create auth middlewares
-	authMiddleware := middleware.AuthMiddleware(userService)
-	adminMiddleware := middleware.AdminMiddleware(userService)
-	optionalAuthMiddleware := middleware.OptionalAuthMiddleware(userService)
+	authMiddleware := middleware.AuthMiddleware(deps.UserService)
+	adminMiddleware := middleware.AdminMiddleware(deps.UserService)
+	optionalAuthMiddleware := middleware.OptionalAuthMiddleware(deps.UserService)
+	idempotencyMiddleware := middleware.Idempotency(deps.IdempotencyService)
+	webhookAllowlist := middleware.IPAllowlist(deps.WebhookAllowedIPs)
 
 	// Static files
 	router.Static("/static", "./static")
@@ -41,6 +69,7 @@ func SetupRouter(
 			"title": "Top Up Game - Home",
 		})
 	})
+	router.GET("/reset-password", accountHandler.ResetPasswordPage)
 
 	// Register routes for each handler
 	userHandler.RegisterRoutes(router)
@@ -53,14 +82,25 @@ func SetupRouter(
 		// Public endpoints
 		api.GET("/products", productHandler.ListProducts)
 		api.GET("/products/:id", productHandler.GetProduct)
-		api.POST("/checkout", optionalAuthMiddleware, transactionHandler.Checkout)
+		api.GET("/games", gameHandler.ListGames)
+		api.GET("/games/:slug/products", gameHandler.GetGameProducts)
+		api.POST("/games/:slug/validate-account", gameHandler.ValidateAccount)
+		api.POST("/checkout", optionalAuthMiddleware, idempotencyMiddleware, transactionHandler.Checkout)
 		api.GET("/transaction/:invoice", transactionHandler.GetTransactionStatus)
+		api.POST("/payments/callback", paymentHandler.Callback)
+		api.POST("/webhooks/vip-reseller", webhookAllowlist, webhookHandler.VIPReseller)
 
 		// Auth endpoints
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", userHandler.Login)
 			auth.POST("/register", userHandler.Register)
+			auth.POST("/refresh", userHandler.Refresh)
+			auth.POST("/logout", authMiddleware, userHandler.Logout)
+			auth.POST("/forgot-password", accountHandler.ForgotPassword)
+			auth.POST("/reset-password", accountHandler.ResetPassword)
+			auth.GET("/verify-email", accountHandler.VerifyEmail)
+			auth.POST("/verify-email", accountHandler.VerifyEmail)
 		}
 
 		// Protected endpoints
@@ -68,7 +108,15 @@ func SetupRouter(
 		protected.Use(authMiddleware)
 		{
 			protected.GET("/profile", userHandler.GetProfile)
+			protected.PUT("/password", userHandler.ChangePassword)
+			protected.POST("/verify-email/resend", accountHandler.ResendVerification)
+			protected.GET("/reseller-application", applicationHandler.GetMyApplication)
+			protected.POST("/reseller-application", applicationHandler.Apply)
 			protected.GET("/transactions", transactionHandler.GetUserTransactions)
+			protected.GET("/wallet", walletHandler.GetWallet)
+			protected.GET("/wallet/entries", walletHandler.GetEntries)
+			protected.GET("/wallet/top-ups", walletHandler.GetTopUps)
+			protected.POST("/wallet/top-ups", idempotencyMiddleware, walletHandler.CreateTopUp)
 		}
 
 		// Admin endpoints
@@ -80,10 +128,57 @@ func SetupRouter(
 			admin.PUT("/products/:id", productHandler.UpdateProduct)
 			admin.DELETE("/products/:id", productHandler.DeleteProduct)
 			admin.POST("/products/sync", productHandler.SyncProducts)
+			admin.GET("/products/sync/runs", productHandler.ListSyncRuns)
+			admin.GET("/products/:id/suppliers", productHandler.GetProductSuppliers)
+			admin.PUT("/products/:id/suppliers", productHandler.SetProductSuppliers)
+			admin.POST("/products/:id/reprice", productHandler.RepriceProduct)
+
+			// Game catalog
+			admin.GET("/games", gameHandler.AdminListGames)
+			admin.POST("/games", gameHandler.CreateGame)
+			admin.GET("/games/:id", gameHandler.GetGame)
+			admin.PUT("/games/:id", gameHandler.UpdateGame)
+			admin.DELETE("/games/:id", gameHandler.DeleteGame)
 
 			// Transaction management
 			admin.GET("/transactions", transactionHandler.ListTransactions)
 			admin.GET("/transactions/:id", transactionHandler.GetTransaction)
+			admin.GET("/transactions/:id/events", transactionHandler.GetTransactionEvents)
+			admin.POST("/transactions/:id/cancel", transactionHandler.CancelTransaction)
+			admin.POST("/transactions/:id/resolve", transactionHandler.ResolveReview)
+			admin.POST("/transactions/:id/refund", refundHandler.RefundTransaction)
+
+			// Refunds
+			admin.GET("/refunds", refundHandler.ListRefunds)
+			admin.GET("/refunds/:id", refundHandler.GetRefund)
+
+			// Pricing
+			admin.GET("/pricing/tiers", pricingHandler.ListTiers)
+			admin.POST("/pricing/tiers", pricingHandler.CreateTier)
+			admin.GET("/pricing/tiers/:id", pricingHandler.GetTier)
+			admin.PUT("/pricing/tiers/:id", pricingHandler.UpdateTier)
+			admin.DELETE("/pricing/tiers/:id", pricingHandler.DeleteTier)
+			admin.GET("/pricing/margin-alerts", productHandler.MarginAlerts)
+
+			// Users
+			admin.GET("/users", adminUserHandler.ListUsers)
+			admin.POST("/users", adminUserHandler.CreateUser)
+			admin.GET("/users/:id", adminUserHandler.GetUser)
+			admin.DELETE("/users/:id", adminUserHandler.DeleteUser)
+			admin.PUT("/users/:id/role", adminUserHandler.ChangeRole)
+			admin.POST("/users/:id/suspend", adminUserHandler.SuspendUser)
+			admin.POST("/users/:id/unsuspend", adminUserHandler.UnsuspendUser)
+			admin.POST("/users/:id/reset-password", adminUserHandler.ResetPassword)
+			admin.GET("/users/:id/audit-logs", adminUserHandler.GetUserAuditLogs)
+			admin.GET("/audit-logs", adminUserHandler.ListAuditLogs)
+			admin.GET("/reseller-applications", applicationHandler.ListApplications)
+			admin.GET("/reseller-applications/:id", applicationHandler.GetApplication)
+			admin.POST("/reseller-applications/:id/approve", applicationHandler.ApproveApplication)
+			admin.POST("/reseller-applications/:id/reject", applicationHandler.RejectApplication)
+
+			// Wallets
+			admin.GET("/users/:id/wallet", walletHandler.GetUserWallet)
+			admin.POST("/users/:id/wallet/adjustments", walletHandler.AdjustBalance)
 		}
 	}
 
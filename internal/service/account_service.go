@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"topup-game/internal/mailer"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var ErrEmailAlreadyVerified = errors.New("email is already verified")
+
+// AccountConfig holds the settings of emailed account links
+type AccountConfig struct {
+	// BaseURL is the address of the storefront the links point to
+	BaseURL              string
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+}
+
+// AccountService proves ownership of email addresses with single-use links,
+// for verifying them and for resetting forgotten passwords
+type AccountService interface {
+	// SendVerification emails the user a link to verify their address
+	SendVerification(ctx context.Context, userID uint) error
+	VerifyEmail(token string) (*model.User, error)
+	// ForgotPassword emails a reset link when the address belongs to an
+	// active user and returns nil for any other address. Callers answer the
+	// same way when it fails, or it would tell which addresses have
+	// accounts.
+	ForgotPassword(ctx context.Context, email string) error
+	// ResetPassword sets a new password with a token from ForgotPassword and
+	// ends every session of the user
+	ResetPassword(token, password string) error
+}
+
+type accountService struct {
+	uow           repository.UnitOfWork
+	userRepo      repository.UserRepository
+	userTokenRepo repository.UserTokenRepository
+	userService   UserService
+	mailer        mailer.Mailer
+	config        AccountConfig
+}
+
+func NewAccountService(
+	uow repository.UnitOfWork,
+	userRepo repository.UserRepository,
+	userTokenRepo repository.UserTokenRepository,
+	userService UserService,
+	mailer mailer.Mailer,
+	config AccountConfig,
+) AccountService {
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &accountService{
+		uow:           uow,
+		userRepo:      userRepo,
+		userTokenRepo: userTokenRepo,
+		userService:   userService,
+		mailer:        mailer,
+		config:        config,
+	}
+}
+
+func (s *accountService) SendVerification(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issue(user.ID, model.TokenEmailVerification, s.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Open this link to verify your email address:\n\n%s\n\nThe link expires in %s.",
+			s.link("/api/auth/verify-email", token), s.config.EmailVerificationTTL),
+	})
+}
+
+func (s *accountService) VerifyEmail(token string) (*model.User, error) {
+	var user *model.User
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		userToken, err := repos.UserTokens.Consume(hashToken(token), model.TokenEmailVerification, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if user, err = repos.Users.FindByID(userToken.UserID); err != nil {
+			return err
+		}
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		verifiedAt := time.Now()
+		user.EmailVerifiedAt = &verifiedAt
+		return repos.Users.Update(user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *accountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status == model.UserSuspended {
+		return nil
+	}
+
+	token, err := s.issue(user.ID, model.TokenPasswordReset, s.config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account. Open this link to choose a new one:\n\n%s\n\nThe link expires in %s. If you did not ask for it, you can ignore this email.",
+			s.link("/reset-password", token), s.config.PasswordResetTTL),
+	})
+}
+
+func (s *accountService) ResetPassword(token, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var userID uint
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		now := time.Now()
+		userToken, err := repos.UserTokens.Consume(hashToken(token), model.TokenPasswordReset, now)
+		if err != nil {
+			return err
+		}
+
+		user, err := repos.Users.FindByID(userToken.UserID)
+		if err != nil {
+			return err
+		}
+		if user.Status == model.UserSuspended {
+			return ErrAccountSuspended
+		}
+
+		user.Password = hashedPassword
+		// Receiving the link proves the address as well
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &now
+		}
+		if err := repos.Users.Update(user); err != nil {
+			return err
+		}
+
+		userID = user.ID
+		return repos.UserTokens.Invalidate(user.ID, model.TokenPasswordReset, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.userService.LogoutAll(userID)
+}
+
+// issue creates a token for the user, invalidating the ones sent before so
+// that only the latest link works
+func (s *accountService) issue(userID uint, purpose model.UserTokenPurpose, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		if err := repos.UserTokens.Invalidate(userID, purpose, now); err != nil {
+			return err
+		}
+		return repos.UserTokens.Create(&model.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashToken(token),
+			ExpiresAt: now.Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *accountService) link(path, token string) string {
+	return s.config.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
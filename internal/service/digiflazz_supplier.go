@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Digiflazz response codes that identify why a transaction was rejected
+var digiflazzErrorCodes = map[string]error{
+	"43": ErrSKUUnavailable,
+	"44": ErrInsufficientBalance,
+	"51": ErrInvalidGameID,
+	"52": ErrInvalidGameID,
+	"53": ErrSKUUnavailable,
+	"54": ErrInvalidGameID,
+	"55": ErrSKUUnavailable,
+}
+
+// digiflazzSupplier places orders with a Digiflazz-style prepaid API. Requests
+// are signed with md5(username + apiKey + ref_id) and an order is looked up
+// by sending the same transaction request again with the same ref_id.
+type digiflazzSupplier struct {
+	baseURL  string
+	username string
+	apiKey   string
+	client   *supplierClient
+}
+
+func NewDigiflazzSupplier(baseURL, username, apiKey string, options ClientOptions) Supplier {
+	return &digiflazzSupplier{
+		baseURL:  baseURL,
+		username: username,
+		apiKey:   apiKey,
+		client:   newSupplierClient(options),
+	}
+}
+
+type digiflazzTransactionRequest struct {
+	Username     string `json:"username"`
+	BuyerSKUCode string `json:"buyer_sku_code"`
+	CustomerNo   string `json:"customer_no"`
+	RefID        string `json:"ref_id"`
+	Sign         string `json:"sign"`
+}
+
+type digiflazzTransactionResponse struct {
+	Data struct {
+		RefID   string `json:"ref_id"`
+		Status  string `json:"status"`
+		RC      string `json:"rc"`
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+func (s *digiflazzSupplier) Name() string {
+	return SupplierDigiflazz
+}
+
+func (s *digiflazzSupplier) Available() bool {
+	return s.client.Available()
+}
+
+func (s *digiflazzSupplier) CreateOrder(ctx context.Context, order SupplierOrder) (*SupplierOrderStatus, error) {
+	status, err := s.transaction(ctx, false, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.State == SupplierOrderFailed {
+		return nil, status.rejection
+	}
+	return &status.SupplierOrderStatus, nil
+}
+
+func (s *digiflazzSupplier) CheckStatus(ctx context.Context, _ string, order SupplierOrder) (*SupplierOrderStatus, error) {
+	// Repeating a ref_id never places a second order, so lookups can be retried
+	status, err := s.transaction(ctx, true, order)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status.SupplierOrderStatus, nil
+}
+
+type digiflazzStatus struct {
+	SupplierOrderStatus
+	rejection *SupplierError
+}
+
+func (s *digiflazzSupplier) transaction(ctx context.Context, idempotent bool, order SupplierOrder) (*digiflazzStatus, error) {
+	jsonData, err := json.Marshal(digiflazzTransactionRequest{
+		Username:     s.username,
+		BuyerSKUCode: order.SKU,
+		CustomerNo:   order.GameID + order.GameServer,
+		RefID:        order.RefID,
+		Sign:         s.sign(order.RefID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal order data: %v", err)
+	}
+
+	resp, err := s.client.do(ctx, idempotent, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/transaction", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	var response digiflazzTransactionResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.Data.RC == "" {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("API returned status code %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	data := response.Data
+	status := &digiflazzStatus{
+		SupplierOrderStatus: SupplierOrderStatus{
+			OrderID: order.RefID,
+			Message: data.Message,
+		},
+	}
+
+	switch data.Status {
+	case "Sukses":
+		status.State = SupplierOrderSuccess
+	case "Pending":
+		status.State = SupplierOrderPending
+	default:
+		status.State = SupplierOrderFailed
+		status.rejection = &SupplierError{
+			Supplier: SupplierDigiflazz,
+			Code:     data.RC,
+			Message:  data.Message,
+			cause:    digiflazzErrorCodes[data.RC],
+		}
+	}
+
+	return status, nil
+}
+
+func (s *digiflazzSupplier) sign(refID string) string {
+	sum := md5.Sum([]byte(s.username + s.apiKey + refID))
+	return hex.EncodeToString(sum[:])
+}
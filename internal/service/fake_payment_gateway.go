@@ -0,0 +1,92 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// fakePaymentGateway is a local provider used for development and offline
+// testing. It never contacts a real payment network; callbacks are expected
+// to be posted manually, signed with the configured secret.
+type fakePaymentGateway struct {
+	baseURL string
+	secret  string
+}
+
+func NewFakePaymentGateway(baseURL, callbackSecret string) PaymentGateway {
+	return &fakePaymentGateway{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  callbackSecret,
+	}
+}
+
+func (g *fakePaymentGateway) Provider() string {
+	return "fake"
+}
+
+func (g *fakePaymentGateway) CreateIntent(req PaymentIntentRequest) (*PaymentIntent, error) {
+	reference, err := randomReference()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate payment reference: %v", err)
+	}
+
+	intent := &PaymentIntent{
+		Provider:  g.Provider(),
+		Reference: "FAKE-" + reference,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
+	if req.Method == "bank_transfer" {
+		// Virtual account numbers are digits only
+		intent.VANumber = "8808" + fmt.Sprintf("%012d", time.Now().UnixNano()%1000000000000)
+	} else {
+		intent.PaymentURL = fmt.Sprintf("%s/pay/%s", g.baseURL, intent.Reference)
+	}
+
+	return intent, nil
+}
+
+func (g *fakePaymentGateway) ParseCallback(payload []byte, signature string) (*PaymentCallback, error) {
+	if !verifyPayloadSignature(g.secret, payload, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var callback PaymentCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+	if callback.Invoice == "" || callback.Status == "" {
+		return nil, fmt.Errorf("%w: invoice and status are required", ErrInvalidCallback)
+	}
+	callback.Raw = string(payload)
+
+	return &callback, nil
+}
+
+func (g *fakePaymentGateway) Refund(req RefundRequest) (*RefundResult, error) {
+	if req.Invoice == "" || !req.Amount.IsPositive() {
+		return nil, fmt.Errorf("invalid refund request for invoice %q", req.Invoice)
+	}
+
+	reference, err := randomReference()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refund reference: %v", err)
+	}
+
+	return &RefundResult{
+		Provider:  g.Provider(),
+		Reference: "FAKE-RF-" + reference,
+	}, nil
+}
+
+func randomReference() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var ErrInvalidGameAccount = errors.New("invalid game account")
+
+// GameAccount identifies the in-game account an order is delivered to
+type GameAccount struct {
+	// Game is the slug of the game, or the category of products that do not
+	// belong to a game yet
+	Game       string
+	GameID     string
+	GameServer string
+}
+
+// GameAccountInfo is what is known about a valid game account. Verified is
+// set when the supplier confirmed that the account exists.
+type GameAccountInfo struct {
+	Nickname string `json:"nickname,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+// GameAccountValidator checks game accounts before money is taken for them.
+// Invalid accounts fail with an error wrapping ErrInvalidGameAccount.
+type GameAccountValidator interface {
+	Validate(ctx context.Context, account GameAccount) (*GameAccountInfo, error)
+}
+
+// GameAccountRule describes the account IDs of a game that has no entry in
+// the game catalog, so products still filed under a category are checked
+type GameAccountRule struct {
+	// Game is the slug of the game, e.g. "mobile-legends"
+	Game    string
+	Aliases []string
+	// IDPattern and ServerPattern are regular expressions the game ID and
+	// server must match. Games with an empty ServerPattern have no servers.
+	IDPattern     string
+	ServerPattern string
+	// NicknameCode is the supplier's code for nickname lookups, empty when
+	// the supplier cannot look up accounts of the game
+	NicknameCode string
+}
+
+// DefaultGameAccountRules covers the most sold games. Games without a rule
+// or catalog entry only need a non-empty game ID.
+var DefaultGameAccountRules = []GameAccountRule{
+	{
+		Game:          "mobile-legends",
+		Aliases:       []string{"mlbb", "ml", "mobile-legends-bang-bang"},
+		IDPattern:     `^\d{5,12}$`,
+		ServerPattern: `^\d{4,5}$`,
+		NicknameCode:  "mobile-legends",
+	},
+	{
+		Game:         "free-fire",
+		Aliases:      []string{"ff", "garena-free-fire"},
+		IDPattern:    `^\d{6,12}$`,
+		NicknameCode: "free-fire",
+	},
+	{
+		Game:          "genshin-impact",
+		Aliases:       []string{"genshin"},
+		IDPattern:     `^[1-9]\d{8}$`,
+		ServerPattern: `^(os_asia|os_usa|os_euro|os_cht)$`,
+	},
+	{
+		Game:      "pubg-mobile",
+		Aliases:   []string{"pubgm", "pubg"},
+		IDPattern: `^\d{8,12}$`,
+	},
+}
+
+type gameAccountValidator struct {
+	gameRepo    repository.GameRepository
+	rules       map[string]*model.Game
+	vipReseller VIPResellerService
+}
+
+// NewGameAccountValidator builds a validator that checks accounts against the
+// game catalog, falling back to rules for games not in it. Nicknames are
+// looked up through vipReseller when it is not nil.
+func NewGameAccountValidator(gameRepo repository.GameRepository, rules []GameAccountRule, vipReseller VIPResellerService) (GameAccountValidator, error) {
+	v := &gameAccountValidator{
+		gameRepo:    gameRepo,
+		rules:       make(map[string]*model.Game),
+		vipReseller: vipReseller,
+	}
+
+	for _, rule := range rules {
+		// Rules are checked like catalog games with the same inputs
+		game := &model.Game{
+			Slug:         rule.Game,
+			Name:         rule.Game,
+			NicknameCode: rule.NicknameCode,
+			InputFields: model.GameInputFields{
+				{Name: model.GameInputID, Label: "Game ID", Required: true, Pattern: rule.IDPattern},
+			},
+		}
+		if rule.ServerPattern != "" {
+			game.InputFields = append(game.InputFields, model.GameInputField{
+				Name: model.GameInputServer, Label: "Game server", Required: true, Pattern: rule.ServerPattern,
+			})
+		}
+		if err := game.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid game account rule for %s: %v", rule.Game, err)
+		}
+
+		for _, name := range append([]string{rule.Game}, rule.Aliases...) {
+			v.rules[GameSlug(name)] = game
+		}
+	}
+
+	return v, nil
+}
+
+// GameSlug normalizes a game name or product category, so that
+// "Mobile Legends" and "mobile-legends" name the same game
+func GameSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+func (v *gameAccountValidator) Validate(ctx context.Context, account GameAccount) (*GameAccountInfo, error) {
+	if strings.TrimSpace(account.GameID) == "" {
+		return nil, fmt.Errorf("%w: game ID is required", ErrInvalidGameAccount)
+	}
+
+	slug := GameSlug(account.Game)
+	game, err := v.gameRepo.FindBySlug(slug)
+	if errors.Is(err, repository.ErrGameNotFound) {
+		var ok bool
+		if game, ok = v.rules[slug]; !ok {
+			return &GameAccountInfo{}, nil
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{
+		model.GameInputID:     strings.TrimSpace(account.GameID),
+		model.GameInputServer: strings.TrimSpace(account.GameServer),
+	}
+	for _, field := range game.InputFields {
+		if err := validateGameInput(field, values[field.Name]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Games without servers ignore whatever was sent as one
+	gameServer := ""
+	if game.Field(model.GameInputServer) != nil {
+		gameServer = values[model.GameInputServer]
+	}
+
+	if game.NicknameCode == "" || v.vipReseller == nil {
+		return &GameAccountInfo{}, nil
+	}
+
+	nickname, err := v.vipReseller.CheckNickname(ctx, game.NicknameCode, values[model.GameInputID], gameServer)
+	if err != nil {
+		if errors.Is(err, ErrInvalidGameID) {
+			return nil, fmt.Errorf("%w: account not found", ErrInvalidGameAccount)
+		}
+		// A lookup that could not be completed does not block the purchase;
+		// the format checks above still apply
+		return &GameAccountInfo{}, nil
+	}
+
+	return &GameAccountInfo{Nickname: nickname, Verified: true}, nil
+}
+
+// validateGameInput checks one account input against its field of the game's
+// input schema
+func validateGameInput(field model.GameInputField, value string) error {
+	if value == "" {
+		if field.Required {
+			return fmt.Errorf("%w: %s is required", ErrInvalidGameAccount, field.Label)
+		}
+		return nil
+	}
+
+	if len(field.Options) > 0 {
+		for _, option := range field.Options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s must be one of %s", ErrInvalidGameAccount, field.Label, strings.Join(field.Options, ", "))
+	}
+
+	if field.Pattern != "" {
+		pattern, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for %s: %v", field.Label, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%w: %s does not match the expected format", ErrInvalidGameAccount, field.Label)
+		}
+	}
+	return nil
+}
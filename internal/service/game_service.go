@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var ErrInvalidGame = errors.New("invalid game data")
+
+type GameService interface {
+	CreateGame(game *model.Game) error
+	UpdateGame(game *model.Game) error
+	DeleteGame(id uint) error
+	GetGame(id uint) (*model.Game, error)
+	GetGames(activeOnly bool) ([]model.Game, error)
+	// GetGameProducts returns an active game with its active products
+	GetGameProducts(slug string) (*model.Game, []model.Product, error)
+}
+
+type gameService struct {
+	gameRepo    repository.GameRepository
+	productRepo repository.ProductRepository
+}
+
+func NewGameService(gameRepo repository.GameRepository, productRepo repository.ProductRepository) GameService {
+	return &gameService{
+		gameRepo:    gameRepo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *gameService) CreateGame(game *model.Game) error {
+	if err := game.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGame, err)
+	}
+
+	return s.gameRepo.Create(game)
+}
+
+func (s *gameService) UpdateGame(game *model.Game) error {
+	if err := game.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGame, err)
+	}
+
+	existing, err := s.gameRepo.FindByID(game.ID)
+	if err != nil {
+		return err
+	}
+
+	game.CreatedAt = existing.CreatedAt
+	return s.gameRepo.Update(game)
+}
+
+func (s *gameService) DeleteGame(id uint) error {
+	return s.gameRepo.Delete(id)
+}
+
+func (s *gameService) GetGame(id uint) (*model.Game, error) {
+	return s.gameRepo.FindByID(id)
+}
+
+func (s *gameService) GetGames(activeOnly bool) ([]model.Game, error) {
+	return s.gameRepo.FindAll(activeOnly)
+}
+
+func (s *gameService) GetGameProducts(slug string) (*model.Game, []model.Product, error) {
+	game, err := s.gameRepo.FindBySlug(slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !game.IsActive {
+		return nil, nil, repository.ErrGameNotFound
+	}
+
+	active := true
+	products, err := s.productRepo.FindAll(repository.ProductQueryParams{
+		GameID:   &game.ID,
+		IsActive: &active,
+		SortBy:   "price",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return game, products, nil
+}
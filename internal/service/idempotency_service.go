@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+	ErrRequestInProgress      = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IdempotencyService interface {
+	// Begin claims key for a request. It returns the stored record when the
+	// key was already used for the same request, so its response can be
+	// replayed, or a new pending record when the key is unused.
+	Begin(scope, key, requestHash string) (*model.IdempotencyKey, error)
+	Complete(record *model.IdempotencyKey, status int, body []byte) error
+	Release(record *model.IdempotencyKey) error
+}
+
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+	ttl             time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+	}
+}
+
+func (s *idempotencyService) Begin(scope, key, requestHash string) (*model.IdempotencyKey, error) {
+	record := &model.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+
+	err := s.idempotencyRepo.Create(record)
+	if err == nil {
+		return record, nil
+	}
+	if !errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return nil, err
+	}
+
+	existing, err := s.idempotencyRepo.Find(scope, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys outside the retry window may be reused
+	if time.Now().After(existing.ExpiresAt) {
+		if err := s.idempotencyRepo.Delete(existing.ID); err != nil {
+			return nil, err
+		}
+		return s.Begin(scope, key, requestHash)
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	if !existing.IsCompleted() {
+		return nil, ErrRequestInProgress
+	}
+
+	return existing, nil
+}
+
+func (s *idempotencyService) Complete(record *model.IdempotencyKey, status int, body []byte) error {
+	return s.idempotencyRepo.Complete(record.ID, status, string(body))
+}
+
+// Release forgets a pending key so that the request can be retried, used when
+// the original request failed in a way that should not be replayed
+func (s *idempotencyService) Release(record *model.IdempotencyKey) error {
+	return s.idempotencyRepo.Delete(record.ID)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"topup-game/internal/repository"
+)
+
+// invoiceSeparator joins the parts of an invoice number. Invoice numbers
+// appear in URL paths, so neither it nor the parts may contain a slash.
+const invoiceSeparator = "-"
+
+// unsafeInvoiceChars cannot appear unescaped in a URL path segment
+const unsafeInvoiceChars = "/?#% "
+
+// ErrInvalidInvoice means an invoice number fails its check digit, so it was
+// mistyped rather than never issued
+var ErrInvalidInvoice = errors.New("invalid invoice number")
+
+// InvoiceFormat describes invoice numbers such as INV-20240101-0000428: a
+// prefix, an optional date, and a zero-padded counter followed by an
+// optional check digit
+type InvoiceFormat struct {
+	Prefix string
+	// DateLayout is a Go time layout; the counter restarts for every date.
+	// An empty layout numbers all invoices in one sequence.
+	DateLayout string
+	Digits     int
+	// CheckDigit appends a Luhn digit over the date and counter, so that
+	// mistyped invoice numbers can be told apart from unknown ones
+	CheckDigit bool
+}
+
+// InvoiceGenerator hands out unique invoice numbers
+type InvoiceGenerator interface {
+	Next() (string, error)
+	// Valid reports whether the check digit of an invoice number matches.
+	// Numbers without one, or of another format, are not rejected.
+	Valid(invoice string) bool
+}
+
+type invoiceGenerator struct {
+	invoiceRepo repository.InvoiceRepository
+	format      InvoiceFormat
+	now         func() time.Time
+}
+
+// NewInvoiceGenerator creates a generator for format, which must produce
+// numbers that can be used in URL paths
+func NewInvoiceGenerator(invoiceRepo repository.InvoiceRepository, format InvoiceFormat) (InvoiceGenerator, error) {
+	if format.Prefix == "" || strings.ContainsAny(format.Prefix, unsafeInvoiceChars) {
+		return nil, fmt.Errorf("invoice prefix %q must be set and must not contain any of %q", format.Prefix, unsafeInvoiceChars)
+	}
+	if strings.ContainsAny(time.Now().Format(format.DateLayout), unsafeInvoiceChars) {
+		return nil, fmt.Errorf("invoice date layout %q must not produce any of %q", format.DateLayout, unsafeInvoiceChars)
+	}
+
+	return &invoiceGenerator{
+		invoiceRepo: invoiceRepo,
+		format:      format,
+		now:         time.Now,
+	}, nil
+}
+
+func (g *invoiceGenerator) Next() (string, error) {
+	parts := []string{g.format.Prefix}
+	if g.format.DateLayout != "" {
+		parts = append(parts, g.now().Format(g.format.DateLayout))
+	}
+
+	value, err := g.invoiceRepo.Next(strings.Join(parts, invoiceSeparator))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate invoice number: %v", err)
+	}
+
+	counter := fmt.Sprintf("%0*d", g.format.Digits, value)
+	if g.format.CheckDigit {
+		counter += strconv.Itoa(luhnCheckDigit(strings.Join(parts[1:], "") + counter))
+	}
+
+	return strings.Join(append(parts, counter), invoiceSeparator), nil
+}
+
+func (g *invoiceGenerator) Valid(invoice string) bool {
+	prefix := g.format.Prefix + invoiceSeparator
+	if !g.format.CheckDigit || !strings.HasPrefix(invoice, prefix) {
+		return true
+	}
+
+	// The check digit covers the digits of the date and counter
+	rest := strings.TrimPrefix(invoice, prefix)
+	if rest == "" {
+		return false
+	}
+	last := rest[len(rest)-1]
+	if last < '0' || last > '9' {
+		return false
+	}
+	return luhnCheckDigit(rest[:len(rest)-1]) == int(last-'0')
+}
+
+// luhnCheckDigit returns the Luhn check digit of the digits in s; other
+// characters are ignored
+func luhnCheckDigit(s string) int {
+	sum := 0
+	double := true
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		digit := int(s[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var ErrInvalidLedgerAmount = errors.New("ledger amount must be greater than 0")
+
+// ledgerAccount is one side of a ledger transfer. Wallet accounts carry the
+// wallet whose balance moves with them; system accounts have no balance.
+type ledgerAccount struct {
+	name   string
+	wallet *model.Wallet
+}
+
+func walletAccount(wallet *model.Wallet) ledgerAccount {
+	return ledgerAccount{name: wallet.Account(), wallet: wallet}
+}
+
+func systemAccount(name string) ledgerAccount {
+	return ledgerAccount{name: name}
+}
+
+type ledgerTransfer struct {
+	Type        model.LedgerEntryType
+	From        ledgerAccount
+	To          ledgerAccount
+	Amount      model.Money
+	Reference   string
+	Description string
+	ActorID     *uint
+}
+
+// postTransfer moves a positive amount from one account to another, posting
+// both ledger entries and updating wallet balances. It must run inside a unit
+// of work so that balances and entries never disagree.
+func postTransfer(wallets repository.WalletRepository, transfer ledgerTransfer) error {
+	if !transfer.Amount.IsPositive() {
+		return ErrInvalidLedgerAmount
+	}
+
+	transferID, err := newTransferID()
+	if err != nil {
+		return err
+	}
+
+	debit := model.Money{Minor: -transfer.Amount.Minor, Currency: transfer.Amount.Currency}
+	entries := make([]model.LedgerEntry, 0, 2)
+	for _, side := range []struct {
+		account ledgerAccount
+		amount  model.Money
+	}{
+		// The debit goes first so that an overdrawn wallet fails early
+		{transfer.From, debit},
+		{transfer.To, transfer.Amount},
+	} {
+		entry := model.LedgerEntry{
+			TransferID:  transferID,
+			Account:     side.account.name,
+			Type:        transfer.Type,
+			Amount:      side.amount,
+			Reference:   transfer.Reference,
+			Description: transfer.Description,
+			ActorID:     transfer.ActorID,
+		}
+
+		if wallet := side.account.wallet; wallet != nil {
+			if err := wallets.AddBalance(wallet.ID, side.amount); err != nil {
+				return err
+			}
+			wallet.Balance.Minor += side.amount.Minor
+			entry.WalletID = &wallet.ID
+		}
+		entries = append(entries, entry)
+	}
+
+	return wallets.CreateEntries(entries)
+}
+
+func newTransferID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate transfer ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
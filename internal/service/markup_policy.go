@@ -0,0 +1,39 @@
+package service
+
+import "topup-game/internal/model"
+
+// MarkupPolicy prices products from their supplier cost
+type MarkupPolicy struct {
+	// BasisPoints of the cost are added to it (1000 = 10%)
+	BasisPoints int64
+	// Fixed is added on top of the percentage markup
+	Fixed model.Money
+	// RoundTo rounds selling prices up to a multiple of this amount
+	RoundTo model.Money
+	// MinMarginBasisPoints is the lowest acceptable margin over cost. Products
+	// below it are flagged for review rather than repriced.
+	MinMarginBasisPoints int64
+}
+
+// Price returns the selling price for a supplier cost
+func (p MarkupPolicy) Price(cost model.Money) model.Money {
+	minor := cost.Minor + (cost.Minor*p.BasisPoints+5000)/10000 + p.Fixed.Minor
+	return p.Round(model.Money{Minor: minor, Currency: cost.Currency})
+}
+
+// Round rounds a selling price up to a multiple of RoundTo
+func (p MarkupPolicy) Round(price model.Money) model.Money {
+	if step := p.RoundTo.Minor; step > 0 && price.Minor%step != 0 {
+		price.Minor += step - price.Minor%step
+	}
+	return price
+}
+
+// MarginTooLow reports whether selling at price leaves less than the minimum
+// margin over cost. Products without a known cost are never flagged.
+func (p MarkupPolicy) MarginTooLow(price, cost model.Money) bool {
+	if !cost.IsPositive() {
+		return false
+	}
+	return (price.Minor-cost.Minor)*10000 < cost.Minor*p.MinMarginBasisPoints
+}
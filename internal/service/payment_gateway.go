@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"topup-game/internal/model"
+)
+
+var (
+	ErrInvalidSignature       = errors.New("invalid callback signature")
+	ErrInvalidCallback        = errors.New("invalid callback payload")
+	ErrUnknownPaymentProvider = errors.New("unknown payment provider")
+)
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPaid    PaymentStatus = "paid"
+	PaymentStatusFailed  PaymentStatus = "failed"
+	PaymentStatusExpired PaymentStatus = "expired"
+)
+
+// PaymentGateway abstracts a payment provider. CreateIntent asks the provider
+// to start collecting money for an invoice, and ParseCallback authenticates and
+// decodes the notification the provider sends once the customer has paid.
+// Refund returns the money of a paid invoice to the customer.
+type PaymentGateway interface {
+	Provider() string
+	CreateIntent(req PaymentIntentRequest) (*PaymentIntent, error)
+	ParseCallback(payload []byte, signature string) (*PaymentCallback, error)
+	Refund(req RefundRequest) (*RefundResult, error)
+}
+
+type PaymentIntentRequest struct {
+	Invoice string
+	Amount  model.Money
+	Method  string
+}
+
+type PaymentIntent struct {
+	Provider   string    `json:"provider"`
+	Reference  string    `json:"reference"`
+	PaymentURL string    `json:"payment_url,omitempty"`
+	VANumber   string    `json:"va_number,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type PaymentCallback struct {
+	Invoice   string        `json:"invoice"`
+	Reference string        `json:"reference"`
+	Status    PaymentStatus `json:"status"`
+	Amount    model.Money   `json:"amount"`
+
+	// Raw is the payload exactly as received from the provider
+	Raw string `json:"-"`
+}
+
+type RefundRequest struct {
+	Invoice   string
+	Reference string
+	Amount    model.Money
+	Reason    string
+}
+
+type RefundResult struct {
+	Provider  string `json:"provider"`
+	Reference string `json:"reference"`
+}
+
+// NewPaymentGateway returns the gateway implementation for the configured provider
+func NewPaymentGateway(provider, baseURL, callbackSecret string) (PaymentGateway, error) {
+	switch provider {
+	case "", "fake":
+		return NewFakePaymentGateway(baseURL, callbackSecret), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPaymentProvider, provider)
+	}
+}
+
+// signPayload returns the hex encoded HMAC-SHA256 of payload
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyPayloadSignature checks signature against payload in constant time
+func verifyPayloadSignature(secret string, payload []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	expected := signPayload(secret, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var (
+	ErrInvalidPriceTier  = errors.New("invalid price tier data")
+	ErrPriceTierConflict = errors.New("an active price tier already applies to this role or user")
+)
+
+type PricingService interface {
+	CreateTier(tier *model.PriceTier) error
+	UpdateTier(tier *model.PriceTier) error
+	DeleteTier(id uint) error
+	GetTier(id uint) (*model.PriceTier, error)
+	GetTiers() ([]model.PriceTier, error)
+	// PriceFor returns what the user pays for the product. Anonymous buyers
+	// and users without a tier pay the product's list price.
+	PriceFor(product *model.Product, userID *uint) (model.Money, error)
+}
+
+type pricingService struct {
+	tierRepo repository.PriceTierRepository
+	userRepo repository.UserRepository
+	// markup rounds tier prices like list prices
+	markup MarkupPolicy
+}
+
+func NewPricingService(tierRepo repository.PriceTierRepository, userRepo repository.UserRepository, markup MarkupPolicy) PricingService {
+	return &pricingService{
+		tierRepo: tierRepo,
+		userRepo: userRepo,
+		markup:   markup,
+	}
+}
+
+func (s *pricingService) CreateTier(tier *model.PriceTier) error {
+	if err := s.validate(tier); err != nil {
+		return err
+	}
+
+	return s.tierRepo.Create(tier)
+}
+
+func (s *pricingService) UpdateTier(tier *model.PriceTier) error {
+	if _, err := s.tierRepo.FindByID(tier.ID); err != nil {
+		return err
+	}
+	if err := s.validate(tier); err != nil {
+		return err
+	}
+
+	return s.tierRepo.Update(tier)
+}
+
+// validate checks the tier's data and that no other active tier applies to
+// the same role or user, so that a buyer's price is never ambiguous
+func (s *pricingService) validate(tier *model.PriceTier) error {
+	if err := tier.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPriceTier, err)
+	}
+	if !tier.IsActive {
+		return nil
+	}
+
+	var existing *model.PriceTier
+	var err error
+	if tier.UserID != nil {
+		existing, err = s.tierRepo.FindActiveForUser(*tier.UserID)
+	} else {
+		existing, err = s.tierRepo.FindActiveForRole(*tier.Role)
+	}
+	if errors.Is(err, repository.ErrPriceTierNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.ID != tier.ID {
+		return ErrPriceTierConflict
+	}
+	return nil
+}
+
+func (s *pricingService) DeleteTier(id uint) error {
+	return s.tierRepo.Delete(id)
+}
+
+func (s *pricingService) GetTier(id uint) (*model.PriceTier, error) {
+	return s.tierRepo.FindByID(id)
+}
+
+func (s *pricingService) GetTiers() ([]model.PriceTier, error) {
+	return s.tierRepo.FindAll()
+}
+
+func (s *pricingService) PriceFor(product *model.Product, userID *uint) (model.Money, error) {
+	if userID == nil || !product.Cost.IsPositive() {
+		return product.Price, nil
+	}
+
+	tier, err := s.tierFor(*userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPriceTierNotFound) || errors.Is(err, repository.ErrUserNotFound) {
+			return product.Price, nil
+		}
+		return model.Money{}, err
+	}
+
+	rule := tier.RuleFor(product.Category)
+	if rule == nil {
+		return product.Price, nil
+	}
+
+	// A tier is a discount: it never charges more than the list price
+	price := s.markup.Round(rule.Apply(product.Cost))
+	if price.Minor > product.Price.Minor {
+		return product.Price, nil
+	}
+	return price, nil
+}
+
+// tierFor returns the user's own tier, or else the tier of their role
+func (s *pricingService) tierFor(userID uint) (*model.PriceTier, error) {
+	tier, err := s.tierRepo.FindActiveForUser(userID)
+	if !errors.Is(err, repository.ErrPriceTierNotFound) {
+		return tier, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tierRepo.FindActiveForRole(user.Role)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"topup-game/internal/model"
@@ -9,7 +10,9 @@ import (
 
 var (
 	ErrInvalidProduct = errors.New("invalid product data")
-	ErrOutOfStock    = errors.New("product is out of stock")
+	ErrOutOfStock     = errors.New("product is out of stock")
+	ErrInvalidMapping = errors.New("invalid product supplier mapping")
+	ErrUnknownCost    = errors.New("product has no supplier cost")
 )
 
 type ProductService interface {
@@ -20,18 +23,30 @@ type ProductService interface {
 	GetProducts(params repository.ProductQueryParams) ([]model.Product, error)
 	GetProductsByCategory(category string) ([]model.Product, error)
 	UpdateStock(id uint, quantity int) error
-	SyncProductsWithVIPReseller() error
+	SyncProductsWithVIPReseller(ctx context.Context, options SyncOptions) (*model.SyncRun, error)
+	GetSyncRuns(params repository.SyncRunQueryParams) ([]model.SyncRun, error)
+	RepriceProduct(id uint) (*model.Product, error)
+	GetProductSuppliers(productID uint) ([]model.ProductSupplier, error)
+	SetProductSuppliers(productID uint, suppliers []model.ProductSupplier) error
 }
 
 type productService struct {
+	uow         repository.UnitOfWork
 	productRepo repository.ProductRepository
+	syncRunRepo repository.SyncRunRepository
 	vipReseller VIPResellerService
+	suppliers   SupplierRegistry
+	markup      MarkupPolicy
 }
 
-func NewProductService(productRepo repository.ProductRepository, vipReseller VIPResellerService) ProductService {
+func NewProductService(uow repository.UnitOfWork, productRepo repository.ProductRepository, syncRunRepo repository.SyncRunRepository, vipReseller VIPResellerService, suppliers SupplierRegistry, markup MarkupPolicy) ProductService {
 	return &productService{
+		uow:         uow,
 		productRepo: productRepo,
+		syncRunRepo: syncRunRepo,
 		vipReseller: vipReseller,
+		suppliers:   suppliers,
+		markup:      markup,
 	}
 }
 
@@ -40,6 +55,7 @@ func (s *productService) CreateProduct(product *model.Product) error {
 		return fmt.Errorf("%w: %v", ErrInvalidProduct, err)
 	}
 
+	product.MarginAlert = s.markup.MarginTooLow(product.Price, product.Cost)
 	return s.productRepo.Create(product)
 }
 
@@ -57,10 +73,13 @@ func (s *productService) UpdateProduct(product *model.Product) error {
 	// Update only allowed fields
 	existing.Name = product.Name
 	existing.Category = product.Category
+	existing.GameID = product.GameID
 	existing.Price = product.Price
+	existing.Cost = product.Cost
 	existing.Description = product.Description
 	existing.IsActive = product.IsActive
 	existing.SKU = product.SKU
+	existing.MarginAlert = s.markup.MarginTooLow(existing.Price, existing.Cost)
 
 	return s.productRepo.Update(existing)
 }
@@ -82,59 +101,66 @@ func (s *productService) GetProductsByCategory(category string) ([]model.Product
 }
 
 func (s *productService) UpdateStock(id uint, quantity int) error {
-	// Get current product
-	product, err := s.productRepo.FindByID(id)
-	if err != nil {
+	// Negative quantities must not take stock below zero
+	if quantity < 0 {
+		err := s.productRepo.ReserveStock(id, -quantity)
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return ErrOutOfStock
+		}
 		return err
 	}
 
-	// Check if we have enough stock for negative quantities
-	if quantity < 0 && (product.Stock + quantity) < 0 {
-		return ErrOutOfStock
+	return s.productRepo.UpdateStock(id, quantity)
+}
+
+func (s *productService) GetProductSuppliers(productID uint) ([]model.ProductSupplier, error) {
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		return nil, err
 	}
 
-	return s.productRepo.UpdateStock(id, quantity)
+	return s.productRepo.FindSuppliers(productID, false)
 }
 
-func (s *productService) SyncProductsWithVIPReseller() error {
-	// Get products from VIP Reseller API
-	vipProducts, err := s.vipReseller.GetGameFeatures()
-	if err != nil {
-		return fmt.Errorf("failed to fetch VIP Reseller products: %v", err)
+// SetProductSuppliers replaces the suppliers a product is ordered from. Each
+// supplier must be configured and may appear only once.
+func (s *productService) SetProductSuppliers(productID uint, suppliers []model.ProductSupplier) error {
+	if _, err := s.productRepo.FindByID(productID); err != nil {
+		return err
 	}
 
-	// For each VIP Reseller product, create or update in our database
-	for _, vipProduct := range vipProducts {
-		product, err := s.productRepo.FindBySKU(vipProduct.SKU)
-		if err != nil {
-			if errors.Is(err, repository.ErrProductNotFound) {
-				// Create new product
-				newProduct := &model.Product{
-					Name:        vipProduct.Name,
-					Category:    vipProduct.Category,
-					Price:      vipProduct.Price,
-					Description: vipProduct.Description,
-					SKU:        vipProduct.SKU,
-					IsActive:   true,
-					Stock:      vipProduct.Stock,
-				}
-				if err := s.CreateProduct(newProduct); err != nil {
-					return fmt.Errorf("failed to create product %s: %v", vipProduct.SKU, err)
-				}
-			} else {
-				return fmt.Errorf("error checking product %s: %v", vipProduct.SKU, err)
-			}
-		} else {
-			// Update existing product
-			product.Name = vipProduct.Name
-			product.Price = vipProduct.Price
-			product.Description = vipProduct.Description
-			product.Stock = vipProduct.Stock
-			if err := s.UpdateProduct(product); err != nil {
-				return fmt.Errorf("failed to update product %s: %v", vipProduct.SKU, err)
-			}
+	seen := make(map[string]bool, len(suppliers))
+	for _, supplier := range suppliers {
+		if _, err := s.suppliers.Get(supplier.Supplier); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidMapping, err)
+		}
+		if supplier.SKU == "" {
+			return fmt.Errorf("%w: SKU is required for %s", ErrInvalidMapping, supplier.Supplier)
 		}
+		if seen[supplier.Supplier] {
+			return fmt.Errorf("%w: %s is listed more than once", ErrInvalidMapping, supplier.Supplier)
+		}
+		seen[supplier.Supplier] = true
+	}
+
+	return s.productRepo.ReplaceSuppliers(productID, suppliers)
+}
+
+// RepriceProduct sets the selling price from the supplier cost using the
+// markup policy, clearing any margin alert
+func (s *productService) RepriceProduct(id uint) (*model.Product, error) {
+	product, err := s.productRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Cost.IsPositive() {
+		return nil, ErrUnknownCost
+	}
+
+	product.Price = s.markup.Price(product.Cost)
+	product.MarginAlert = s.markup.MarginTooLow(product.Price, product.Cost)
+	if err := s.productRepo.Update(product); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return product, nil
 }
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+// SyncOptions control a catalog sync
+type SyncOptions struct {
+	// TriggeredBy is the admin who asked for the sync, nil for scheduled syncs
+	TriggeredBy *uint
+	// DryRun computes the report without changing the catalog or recording
+	// the run
+	DryRun bool
+}
+
+// syncPlan holds the changes a sync makes to the catalog and the report
+// describing them
+type syncPlan struct {
+	report model.SyncReport
+	create []*model.Product
+	// update also holds the products to deactivate or reactivate
+	update []*model.Product
+	// stock holds the products whose stock VIP Reseller reports changed
+	stock []stockChange
+}
+
+// stockChange is the stock VIP Reseller now reports for a product
+type stockChange struct {
+	product *model.Product
+	stock   int
+}
+
+// SyncProductsWithVIPReseller brings the catalog in line with VIP Reseller's
+// and records the run. Products it no longer lists are deactivated and
+// reactivated when it lists them again. Stock only follows the supplier's
+// changes, so units reserved by open transactions stay reserved. Items that
+// cannot be applied are reported as failed; everything else is applied in a
+// single database transaction, so a run never leaves the catalog half synced.
+func (s *productService) SyncProductsWithVIPReseller(ctx context.Context, options SyncOptions) (*model.SyncRun, error) {
+	run := &model.SyncRun{
+		Supplier:    SupplierVIPReseller,
+		Status:      model.SyncRunning,
+		TriggeredBy: options.TriggeredBy,
+		DryRun:      options.DryRun,
+		StartedAt:   time.Now(),
+	}
+	if !run.DryRun {
+		if err := s.syncRunRepo.Create(run); err != nil {
+			return nil, err
+		}
+	}
+
+	syncErr := s.syncVIPReseller(ctx, run)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	switch {
+	case syncErr != nil:
+		run.Status = model.SyncFailed
+		run.Error = syncErr.Error()
+		// The transaction was rolled back, so none of the items were applied
+		run.SyncReport = model.SyncReport{}
+	case run.Failed > 0:
+		run.Status = model.SyncPartial
+	default:
+		run.Status = model.SyncSucceeded
+	}
+	if run.DryRun {
+		return run, syncErr
+	}
+	if err := s.syncRunRepo.Update(run); err != nil && syncErr == nil {
+		syncErr = fmt.Errorf("failed to record sync run: %v", err)
+	}
+
+	return run, syncErr
+}
+
+func (s *productService) GetSyncRuns(params repository.SyncRunQueryParams) ([]model.SyncRun, error) {
+	return s.syncRunRepo.FindAll(params)
+}
+
+func (s *productService) syncVIPReseller(ctx context.Context, run *model.SyncRun) error {
+	vipProducts, err := s.vipReseller.GetGameFeatures(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch VIP Reseller products: %v", err)
+	}
+
+	if run.DryRun {
+		plan, err := s.planSync(s.productRepo, vipProducts)
+		if err != nil {
+			return err
+		}
+
+		run.SyncReport = plan.report
+		return nil
+	}
+
+	return s.uow.Do(func(repos repository.Repositories) error {
+		plan, err := s.planSync(repos.Products, vipProducts)
+		if err != nil {
+			return err
+		}
+
+		run.SyncReport = plan.report
+		return plan.apply(repos.Products)
+	})
+}
+
+// planSync compares the VIP Reseller catalog with ours. Products that have
+// supplier mappings are ordered under the mapped SKUs, so they are never
+// deactivated because VIP Reseller stopped listing their own SKU.
+func (s *productService) planSync(products repository.ProductRepository, vipProducts []VIPProduct) (*syncPlan, error) {
+	existing, err := products.FindAllWithSKU()
+	if err != nil {
+		return nil, err
+	}
+	mappedIDs, err := products.FindMappedProductIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	bySKU := make(map[string]*model.Product, len(existing))
+	for i := range existing {
+		bySKU[existing[i].SKU] = &existing[i]
+	}
+	mapped := make(map[uint]bool, len(mappedIDs))
+	for _, id := range mappedIDs {
+		mapped[id] = true
+	}
+
+	plan := &syncPlan{}
+	listed := make(map[string]bool, len(vipProducts))
+	for _, vipProduct := range vipProducts {
+		item := model.SyncItem{SKU: vipProduct.SKU, Name: vipProduct.Name}
+		fail := func(reason string) {
+			item.Action = model.SyncItemFailed
+			item.Reason = reason
+			plan.report.Add(item)
+		}
+
+		if vipProduct.SKU == "" {
+			fail("SKU is missing")
+			continue
+		}
+		if listed[vipProduct.SKU] {
+			fail("SKU is listed more than once")
+			continue
+		}
+		listed[vipProduct.SKU] = true
+
+		// The product is left as it is rather than deactivated
+		if vipProduct.Invalid != "" {
+			fail(vipProduct.Invalid)
+			continue
+		}
+
+		product, ok := bySKU[vipProduct.SKU]
+		if !ok {
+			// New products are priced by the markup policy
+			product = &model.Product{
+				Name:        vipProduct.Name,
+				Category:    vipProduct.Category,
+				Price:       s.markup.Price(vipProduct.Price),
+				Cost:        vipProduct.Price,
+				Description: vipProduct.Description,
+				SKU:         vipProduct.SKU,
+				IsActive:    true,
+				Stock:       vipProduct.Stock,
+			}
+			supplierStock := vipProduct.Stock
+			product.SupplierStock = &supplierStock
+			if err := product.Validate(); err != nil {
+				fail(err.Error())
+				continue
+			}
+			product.MarginAlert = s.markup.MarginTooLow(product.Price, product.Cost)
+
+			plan.create = append(plan.create, product)
+			item.Action = model.SyncCreated
+			plan.report.Add(item)
+			continue
+		}
+
+		// Deleted products stay deleted; their SKU is listed on every run,
+		// so reporting it would leave every run partial
+		if product.DeletedAt.Valid {
+			plan.report.Unchanged++
+			continue
+		}
+		catalogChanged := product.Name != vipProduct.Name || !product.Cost.Equal(vipProduct.Price) ||
+			product.Description != vipProduct.Description || !product.IsActive
+		stockChanged := product.SupplierStock == nil || *product.SupplierStock != vipProduct.Stock
+		if !catalogChanged && !stockChanged {
+			plan.report.Unchanged++
+			continue
+		}
+
+		item.Action = model.SyncUpdated
+		if catalogChanged {
+			if !product.IsActive {
+				item.Action = model.SyncReactivated
+				item.Reason = "listed by VIP Reseller again"
+			}
+
+			// The selling price is kept: a product whose margin no longer
+			// holds is flagged for review instead
+			product.Name = vipProduct.Name
+			product.Cost = vipProduct.Price
+			product.Description = vipProduct.Description
+			product.IsActive = true
+			if err := product.Validate(); err != nil {
+				fail(err.Error())
+				continue
+			}
+			product.MarginAlert = s.markup.MarginTooLow(product.Price, product.Cost)
+			plan.update = append(plan.update, product)
+		}
+		if stockChanged {
+			plan.stock = append(plan.stock, stockChange{product: product, stock: vipProduct.Stock})
+		}
+		plan.report.Add(item)
+	}
+
+	for i := range existing {
+		product := &existing[i]
+		if listed[product.SKU] || !product.IsActive || product.DeletedAt.Valid || mapped[product.ID] {
+			continue
+		}
+
+		product.IsActive = false
+		plan.update = append(plan.update, product)
+		plan.report.Add(model.SyncItem{
+			SKU:    product.SKU,
+			Name:   product.Name,
+			Action: model.SyncDeactivated,
+			Reason: "no longer listed by VIP Reseller",
+		})
+	}
+
+	return plan, nil
+}
+
+func (p *syncPlan) apply(products repository.ProductRepository) error {
+	for _, product := range p.create {
+		if err := products.Create(product); err != nil {
+			return fmt.Errorf("failed to create product %s: %v", product.SKU, err)
+		}
+	}
+	for _, product := range p.update {
+		if err := products.UpdateCatalog(product); err != nil {
+			return fmt.Errorf("failed to update product %s: %v", product.SKU, err)
+		}
+	}
+	for _, change := range p.stock {
+		if err := products.SyncSupplierStock(change.product.ID, change.stock); err != nil {
+			return fmt.Errorf("failed to update stock of product %s: %v", change.product.SKU, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var (
+	ErrNotRefundable = errors.New("transaction cannot be refunded")
+	ErrRefundFailed  = errors.New("payment provider refused the refund")
+)
+
+type RefundService interface {
+	// RefundTransaction fully refunds a delivered or failed transaction, or
+	// a paid one whose order was not placed, returning its stock. Expired
+	// and cancelled transactions are refunded when they were paid late.
+	RefundTransaction(transactionID uint, actorID uint, reason string) (*model.Refund, error)
+	GetRefund(id uint) (*model.Refund, error)
+	GetRefunds(params repository.RefundQueryParams) ([]model.Refund, error)
+}
+
+type refundService struct {
+	uow             repository.UnitOfWork
+	transactionRepo repository.TransactionRepository
+	refundRepo      repository.RefundRepository
+	paymentGateway  PaymentGateway
+}
+
+func NewRefundService(
+	uow repository.UnitOfWork,
+	transactionRepo repository.TransactionRepository,
+	refundRepo repository.RefundRepository,
+	paymentGateway PaymentGateway,
+) RefundService {
+	return &refundService{
+		uow:             uow,
+		transactionRepo: transactionRepo,
+		refundRepo:      refundRepo,
+		paymentGateway:  paymentGateway,
+	}
+}
+
+func (s *refundService) RefundTransaction(transactionID uint, actorID uint, reason string) (*model.Refund, error) {
+	transaction, err := s.transactionRepo.FindByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Processing transactions have an unknown outcome, so only settled
+	// transactions and paid ones whose order was not placed are refunded
+	switch transaction.Status {
+	case model.StatusSuccess, model.StatusFailed, model.StatusPaid, model.StatusExpired, model.StatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: transaction is %s", ErrNotRefundable, transaction.Status)
+	}
+	if transaction.PaidAt == nil {
+		return nil, fmt.Errorf("%w: transaction was never paid", ErrNotRefundable)
+	}
+
+	// A paid transaction is failed first, with the same compare-and-set that
+	// claims it for fulfillment, so its order can no longer be placed
+	if transaction.Status == model.StatusPaid {
+		err := transitionIn(s.transactionRepo, transaction, model.StatusFailed, StatusChange{
+			Source:  model.EventSourceAdmin,
+			ActorID: &actorID,
+			Reason:  "refund requested before the order was placed",
+		})
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return nil, fmt.Errorf("%w: its order is being placed", ErrNotRefundable)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	refund, err := s.claim(transaction, actorID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// A refund claimed with a provider reference was already refunded by the
+	// provider and only has to be settled
+	if refund.Method == model.RefundToPaymentProvider && refund.ProviderReference == "" {
+		result, err := s.paymentGateway.Refund(RefundRequest{
+			Invoice:   transaction.Invoice,
+			Reference: transaction.PaymentReference,
+			Amount:    refund.Amount,
+			Reason:    reason,
+		})
+		if err != nil {
+			refund.FailureReason = err.Error()
+			_ = s.refundRepo.Update(refund)
+			_ = s.refundRepo.UpdateStatus(refund.ID, model.RefundPending, model.RefundFailed)
+			return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+		}
+
+		// The money is gone once the provider refunded it, so the reference
+		// is saved before settling: a refund that fails to settle stays
+		// pending with it and can be claimed again
+		refund.ProviderReference = result.Reference
+		if err := s.refundRepo.Update(refund); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		return settleRefund(repos, refund, transaction, StatusChange{
+			Source:  model.EventSourceAdmin,
+			ActorID: &actorID,
+			Reason:  reason,
+		})
+	})
+	if err != nil {
+		// Nothing was paid out, so the refund can be retried from scratch
+		if refund.ProviderReference == "" {
+			refund.Status = model.RefundPending
+			refund.CompletedAt = nil
+			refund.FailureReason = err.Error()
+			_ = s.refundRepo.Update(refund)
+			_ = s.refundRepo.UpdateStatus(refund.ID, model.RefundPending, model.RefundFailed)
+		}
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// claim creates the pending refund of a transaction, or takes over its failed
+// one for a retry. A pending refund the provider already paid out is returned
+// as it is, to be settled. Other pending refunds and completed ones are never
+// claimed twice; settling a refund twice fails on its status.
+func (s *refundService) claim(transaction *model.Transaction, actorID uint, reason string) (*model.Refund, error) {
+	refund, err := s.refundRepo.FindByTransactionID(transaction.ID)
+	if errors.Is(err, repository.ErrRefundNotFound) {
+		refund = newRefund(transaction, reason, &actorID)
+		if err := s.refundRepo.Create(refund); err != nil {
+			return nil, err
+		}
+		return refund, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.Status == model.RefundPending && refund.ProviderReference != "" {
+		return refund, nil
+	}
+	if refund.Status != model.RefundFailed {
+		return nil, repository.ErrRefundExists
+	}
+	if err := s.refundRepo.UpdateStatus(refund.ID, model.RefundFailed, model.RefundPending); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return nil, repository.ErrRefundExists
+		}
+		return nil, err
+	}
+
+	refund.Status = model.RefundPending
+	refund.Reason = reason
+	refund.RequestedBy = &actorID
+	refund.FailureReason = ""
+	if err := s.refundRepo.Update(refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *refundService) GetRefund(id uint) (*model.Refund, error) {
+	return s.refundRepo.FindByID(id)
+}
+
+func (s *refundService) GetRefunds(params repository.RefundQueryParams) ([]model.Refund, error) {
+	return s.refundRepo.FindAll(params)
+}
+
+// refundMethod returns where the money of a transaction goes back to
+func refundMethod(transaction *model.Transaction) model.RefundMethod {
+	if transaction.PaymentProvider == PaymentMethodBalance {
+		return model.RefundToWallet
+	}
+	return model.RefundToPaymentProvider
+}
+
+func newRefund(transaction *model.Transaction, reason string, actorID *uint) *model.Refund {
+	return &model.Refund{
+		TransactionID: transaction.ID,
+		Invoice:       transaction.Invoice,
+		Amount:        transaction.Amount,
+		Method:        refundMethod(transaction),
+		Status:        model.RefundPending,
+		Reason:        reason,
+		RequestedBy:   actorID,
+	}
+}
+
+// settleRefund completes a pending refund inside a unit of work: it credits
+// wallet refunds, marks the refund completed, moves the transaction to
+// refunded and returns its stock
+func settleRefund(repos repository.Repositories, refund *model.Refund, transaction *model.Transaction, change StatusChange) error {
+	if refund.Method == model.RefundToWallet {
+		if transaction.UserID == nil {
+			return fmt.Errorf("%w: wallet payment without a user", ErrNotRefundable)
+		}
+		wallet, err := repos.Wallets.FindOrCreate(*transaction.UserID)
+		if err != nil {
+			return err
+		}
+		err = postTransfer(repos.Wallets, ledgerTransfer{
+			Type:        model.LedgerRefund,
+			From:        systemAccount(model.AccountSales),
+			To:          walletAccount(wallet),
+			Amount:      refund.Amount,
+			Reference:   refund.Invoice,
+			Description: "refund of " + refund.Invoice,
+			ActorID:     refund.RequestedBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := repos.Refunds.UpdateStatus(refund.ID, model.RefundPending, model.RefundCompleted); err != nil {
+		return err
+	}
+	completedAt := time.Now()
+	refund.Status = model.RefundCompleted
+	refund.CompletedAt = &completedAt
+	if err := repos.Refunds.Update(refund); err != nil {
+		return err
+	}
+
+	if err := transitionIn(repos.Transactions, transaction, model.StatusRefunded, change); err != nil {
+		return err
+	}
+	// Refunding a late payment is what its review asked for
+	if transaction.NeedsReview {
+		transaction.NeedsReview = false
+		if err := repos.Transactions.Update(transaction); err != nil {
+			return err
+		}
+	}
+	return releaseStock(repos, transaction)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var (
+	ErrInvalidApplication  = errors.New("invalid reseller application")
+	ErrNotEligible         = errors.New("only customer accounts can apply to become a reseller")
+	ErrApplicationReviewed = errors.New("reseller application was already reviewed")
+	ErrReviewNoteRequired  = errors.New("a note is required when rejecting an application")
+)
+
+type ResellerApplicationService interface {
+	// Apply files an application for the user. Only customers (guest
+	// accounts) may apply, and only one application can be pending.
+	Apply(userID uint, application *model.ResellerApplication) error
+	GetUserApplication(userID uint) (*model.ResellerApplication, error)
+	GetApplication(id uint) (*model.ResellerApplication, error)
+	GetApplications(params repository.ResellerApplicationQueryParams) ([]model.ResellerApplication, error)
+	// Approve accepts a pending application and makes its user a reseller
+	Approve(id, reviewerID uint, note string) (*model.ResellerApplication, error)
+	Reject(id, reviewerID uint, note string) (*model.ResellerApplication, error)
+}
+
+type resellerApplicationService struct {
+	uow             repository.UnitOfWork
+	userRepo        repository.UserRepository
+	applicationRepo repository.ResellerApplicationRepository
+}
+
+func NewResellerApplicationService(uow repository.UnitOfWork, userRepo repository.UserRepository, applicationRepo repository.ResellerApplicationRepository) ResellerApplicationService {
+	return &resellerApplicationService{
+		uow:             uow,
+		userRepo:        userRepo,
+		applicationRepo: applicationRepo,
+	}
+}
+
+func (s *resellerApplicationService) Apply(userID uint, application *model.ResellerApplication) error {
+	application.BusinessName = strings.TrimSpace(application.BusinessName)
+	application.Phone = strings.TrimSpace(application.Phone)
+	if application.BusinessName == "" || application.Phone == "" {
+		return fmt.Errorf("%w: business name and phone are required", ErrInvalidApplication)
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Role != model.RoleGuest {
+		return ErrNotEligible
+	}
+
+	application.ID = 0
+	application.UserID = userID
+	application.Status = model.ApplicationPending
+	application.ReviewedBy = nil
+	application.ReviewNote = ""
+	application.ReviewedAt = nil
+	return s.applicationRepo.Create(application)
+}
+
+func (s *resellerApplicationService) GetUserApplication(userID uint) (*model.ResellerApplication, error) {
+	return s.applicationRepo.FindLatestByUserID(userID)
+}
+
+func (s *resellerApplicationService) GetApplication(id uint) (*model.ResellerApplication, error) {
+	return s.applicationRepo.FindByID(id)
+}
+
+func (s *resellerApplicationService) GetApplications(params repository.ResellerApplicationQueryParams) ([]model.ResellerApplication, error) {
+	return s.applicationRepo.FindAll(params)
+}
+
+func (s *resellerApplicationService) Approve(id, reviewerID uint, note string) (*model.ResellerApplication, error) {
+	return s.review(id, reviewerID, model.ApplicationApproved, note)
+}
+
+func (s *resellerApplicationService) Reject(id, reviewerID uint, note string) (*model.ResellerApplication, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, ErrReviewNoteRequired
+	}
+	return s.review(id, reviewerID, model.ApplicationRejected, note)
+}
+
+func (s *resellerApplicationService) review(id, reviewerID uint, to model.ApplicationStatus, note string) (*model.ResellerApplication, error) {
+	application, err := s.applicationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if application.Status != model.ApplicationPending {
+		return nil, ErrApplicationReviewed
+	}
+
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		// Two admins reviewing at once must not both succeed
+		if err := repos.Applications.UpdateStatus(application.ID, model.ApplicationPending, to); err != nil {
+			return err
+		}
+
+		reviewedAt := time.Now()
+		application.ReviewedBy = &reviewerID
+		application.ReviewNote = strings.TrimSpace(note)
+		application.ReviewedAt = &reviewedAt
+		if err := repos.Applications.Update(application); err != nil {
+			return err
+		}
+		if to != model.ApplicationApproved {
+			return nil
+		}
+
+		user, err := repos.Users.FindByID(application.UserID)
+		if err != nil {
+			return err
+		}
+		// Users an admin promoted in the meantime keep their role
+		if user.Role != model.RoleGuest {
+			return nil
+		}
+		user.Role = model.RoleReseller
+		return repos.Users.Update(user)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return nil, ErrApplicationReviewed
+		}
+		return nil, err
+	}
+
+	application.Status = to
+	if application.User != nil && to == model.ApplicationApproved && application.User.Role == model.RoleGuest {
+		application.User.Role = model.RoleReseller
+	}
+	return application, nil
+}
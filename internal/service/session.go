@@ -0,0 +1,183 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenConfig holds the lifetimes of issued tokens
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenPair is handed to a client when a session starts or is refreshed
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the lifetime of the access token in seconds
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// Refresh rotates the refresh token: the presented one is used up and a
+// successor in the same session is issued with the new access token
+func (s *userService) Refresh(refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	token, err := s.tokenRepo.FindRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	err = s.tokenRepo.MarkRefreshTokenUsed(token.ID, now)
+	if errors.Is(err, repository.ErrRefreshTokenUsed) {
+		// Either the client or whoever copied the token is replaying it.
+		// Without knowing which, the whole session ends.
+		if err := s.revokeSession(token.UserID, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if user.Status == model.UserSuspended {
+		return nil, ErrAccountSuspended
+	}
+
+	return s.issueTokens(user, token.FamilyID)
+}
+
+func (s *userService) Logout(claims *Claims) error {
+	// The token is denylisted on its own as well as through its session
+	err := s.tokenRepo.Deny([]model.RevokedToken{{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}})
+	if err != nil {
+		return err
+	}
+
+	return s.revokeSession(claims.UserID, claims.SessionID)
+}
+
+func (s *userService) LogoutAll(userID uint) error {
+	now := time.Now()
+	sessions, err := s.tokenRepo.RevokeUserTokens(userID, now)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]model.RevokedToken, 0, len(sessions))
+	for _, session := range sessions {
+		entries = append(entries, model.RevokedToken{
+			JTI:       session,
+			UserID:    userID,
+			ExpiresAt: now.Add(s.tokens.AccessTTL),
+		})
+	}
+	return s.tokenRepo.Deny(entries)
+}
+
+// revokeSession revokes the refresh tokens of a session and denylists it
+// until the last access token issued to it has expired
+func (s *userService) revokeSession(userID uint, sessionID string) error {
+	now := time.Now()
+	if err := s.tokenRepo.RevokeFamily(sessionID, now); err != nil {
+		return err
+	}
+
+	return s.tokenRepo.Deny([]model.RevokedToken{{
+		JTI:       sessionID,
+		UserID:    userID,
+		ExpiresAt: now.Add(s.tokens.AccessTTL),
+	}})
+}
+
+// issueTokens signs an access token and stores a new refresh token for the
+// session, starting a new session when sessionID is empty
+func (s *userService) issueTokens(user *model.User, sessionID string) (*TokenPair, error) {
+	var err error
+	if sessionID == "" {
+		if sessionID, err = randomToken(16); err != nil {
+			return nil, err
+		}
+	}
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokens.AccessTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tokenRepo.CreateRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  sessionID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: now.Add(s.tokens.RefreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.tokens.AccessTTL / time.Second),
+	}, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is how refresh tokens are stored. They are random, so a plain
+// SHA-256 is enough to make a leaked table useless.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Names under which suppliers are registered and referenced by product
+// supplier mappings and transactions
+const (
+	SupplierVIPReseller = "vip_reseller"
+	SupplierDigiflazz   = "digiflazz"
+)
+
+var ErrUnknownSupplier = errors.New("unknown supplier")
+
+// SupplierOrderState is the normalised state of an order placed with a supplier
+type SupplierOrderState string
+
+const (
+	SupplierOrderPending SupplierOrderState = "pending"
+	SupplierOrderSuccess SupplierOrderState = "success"
+	SupplierOrderFailed  SupplierOrderState = "failed"
+)
+
+// Supplier places top-up orders with an upstream provider
+type Supplier interface {
+	// Name is the key the supplier is registered under
+	Name() string
+	CreateOrder(ctx context.Context, order SupplierOrder) (*SupplierOrderStatus, error)
+	// CheckStatus looks up an order by the ID returned from CreateOrder. The
+	// original order is passed for suppliers that need it to find the order.
+	CheckStatus(ctx context.Context, orderID string, order SupplierOrder) (*SupplierOrderStatus, error)
+	// Available reports whether the supplier is currently accepting calls
+	Available() bool
+}
+
+type SupplierOrder struct {
+	GameID     string `json:"game_id"`
+	GameServer string `json:"game_server"`
+	SKU        string `json:"sku"`
+	// RefID is our reference for the order, used by the supplier to reject duplicates
+	RefID string `json:"ref_id"`
+}
+
+type SupplierOrderStatus struct {
+	OrderID string             `json:"order_id"`
+	State   SupplierOrderState `json:"state"`
+	Message string             `json:"message,omitempty"`
+}
+
+// SupplierError is returned when a supplier definitively rejects a request.
+// It unwraps to ErrInsufficientBalance, ErrInvalidGameID or ErrSKUUnavailable
+// when the rejection identifies one of those causes.
+type SupplierError struct {
+	Supplier string
+	Code     string
+	Message  string
+	cause    error
+}
+
+func (e *SupplierError) Error() string {
+	return fmt.Sprintf("%s rejected the request (%s): %s", e.Supplier, e.Code, e.Message)
+}
+
+func (e *SupplierError) Unwrap() error {
+	return e.cause
+}
+
+// orderRejected reports whether an order that failed with err was certainly
+// not placed: the supplier was never called or answered with a rejection.
+// Timeouts, server errors and other failures leave the outcome unknown.
+func orderRejected(err error) bool {
+	var vipErr *VIPAPIError
+	var supplierErr *SupplierError
+	return errors.Is(err, ErrProviderUnavailable) || errors.As(err, &vipErr) || errors.As(err, &supplierErr)
+}
+
+// canFailOver reports whether an order that failed with err was certainly not
+// placed and might succeed with another supplier
+func canFailOver(err error) bool {
+	// Every supplier will reject the same game account
+	return orderRejected(err) && !errors.Is(err, ErrInvalidGameID)
+}
+
+// SupplierRegistry holds the suppliers configured for this deployment
+type SupplierRegistry interface {
+	Get(name string) (Supplier, error)
+	Names() []string
+}
+
+type supplierRegistry struct {
+	suppliers map[string]Supplier
+}
+
+func NewSupplierRegistry(suppliers ...Supplier) SupplierRegistry {
+	registry := &supplierRegistry{
+		suppliers: make(map[string]Supplier, len(suppliers)),
+	}
+	for _, supplier := range suppliers {
+		registry.suppliers[supplier.Name()] = supplier
+	}
+	return registry
+}
+
+func (r *supplierRegistry) Get(name string) (Supplier, error) {
+	supplier, ok := r.suppliers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSupplier, name)
+	}
+	return supplier, nil
+}
+
+func (r *supplierRegistry) Names() []string {
+	names := make([]string, 0, len(r.suppliers))
+	for name := range r.suppliers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
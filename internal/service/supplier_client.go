@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// maxResponseSize bounds how much of a supplier response body is read
+const maxResponseSize = 1 << 20
+
+// ClientOptions tunes the resilience of a supplier API client
+type ClientOptions struct {
+	// Timeout bounds every single HTTP attempt
+	Timeout time.Duration
+	// MaxRetries is how often idempotent requests are retried
+	MaxRetries int
+	// BreakerThreshold consecutive failures open the circuit breaker
+	BreakerThreshold int
+	// BreakerCooldown is how long the breaker stays open
+	BreakerCooldown time.Duration
+}
+
+// supplierClient sends requests to a supplier API through a circuit breaker
+type supplierClient struct {
+	client     *http.Client
+	maxRetries int
+	breaker    *circuitBreaker
+}
+
+func newSupplierClient(options ClientOptions) *supplierClient {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	return &supplierClient{
+		client: &http.Client{
+			Timeout: options.Timeout,
+		},
+		maxRetries: options.MaxRetries,
+		breaker:    newCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
+	}
+}
+
+// Available reports whether the circuit breaker lets requests through
+func (c *supplierClient) Available() bool {
+	return c.breaker.Available()
+}
+
+// do sends the request built by newRequest through the circuit breaker.
+// Idempotent requests are retried with jittered exponential backoff when the
+// supplier cannot be reached or answers with a server error.
+func (c *supplierClient) do(ctx context.Context, idempotent bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	attempts := 1
+	if idempotent {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, retryBackoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !c.breaker.Allow() {
+			return nil, ErrProviderUnavailable
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			c.breaker.Release()
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			// A caller that went away says nothing about the supplier
+			if ctx.Err() != nil {
+				c.breaker.Release()
+				return nil, ctx.Err()
+			}
+			c.breaker.Failure()
+			lastErr = fmt.Errorf("failed to send request: %v", err)
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			c.breaker.Failure()
+			resp.Body.Close()
+			lastErr = fmt.Errorf("API returned status code %d", resp.StatusCode)
+			continue
+		}
+
+		c.breaker.Success()
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+// retryBackoff returns a random wait of up to 200ms doubled per attempt
+func retryBackoff(attempt int) time.Duration {
+	max := 200 * time.Millisecond << uint(attempt-1)
+	return time.Duration(rand.Int63n(int64(max))) + time.Millisecond
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -1,16 +1,44 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"time"
 	"topup-game/internal/model"
 	"topup-game/internal/repository"
 )
 
+const (
+	// Pending supplier orders are re-checked with exponential backoff between these bounds
+	minSyncBackoff = 30 * time.Second
+	maxSyncBackoff = 30 * time.Minute
+
+	// resumeOrderAfter is how long a paid transaction may wait for its order
+	// before the reconciler places it; checkouts normally take seconds
+	resumeOrderAfter = 2 * time.Minute
+
+	// maxInvoiceAttempts bounds the retries when a generated invoice number
+	// is already taken
+	maxInvoiceAttempts = 3
+
+	// PaymentMethodBalance pays for a checkout from the buyer's wallet
+	PaymentMethodBalance = "balance"
+)
+
 var (
-	ErrInvalidTransaction = errors.New("invalid transaction data")
-	ErrProductUnavailable = errors.New("product is currently unavailable")
+	ErrInvalidTransaction    = errors.New("invalid transaction data")
+	ErrProductUnavailable    = errors.New("product is currently unavailable")
+	ErrPaymentAmountMismatch = errors.New("paid amount does not match transaction amount")
+	ErrLoginRequired         = errors.New("paying from balance requires a signed-in user")
+	ErrNotUnderReview        = errors.New("transaction is not awaiting review")
+	ErrInvalidResolution     = errors.New("a supplier order ID or a final state is required")
+	// ErrLatePayment is returned, with the transaction, for a payment made
+	// after the transaction was expired or cancelled
+	ErrLatePayment = errors.New("payment received for a closed transaction")
 )
 
 type TransactionService interface {
@@ -19,50 +47,105 @@ type TransactionService interface {
 	GetTransactionByInvoice(invoice string) (*model.Transaction, error)
 	GetTransactions(params repository.TransactionQueryParams) ([]model.Transaction, error)
 	GetUserTransactions(userID uint) ([]model.Transaction, error)
-	UpdateTransactionStatus(id uint, status model.TransactionStatus) error
-	ProcessCheckout(checkout CheckoutRequest) (*model.Transaction, error)
-	SyncTransactionStatus(invoice string) error
+	UpdateTransactionStatus(id uint, status model.TransactionStatus, change StatusChange) error
+	GetTransactionEvents(id uint) ([]model.TransactionEvent, error)
+	// ProcessCheckout creates a transaction for a checkout. When it fails
+	// after the transaction was saved, the transaction is returned along
+	// with the error.
+	ProcessCheckout(ctx context.Context, checkout CheckoutRequest) (*model.Transaction, error)
+	ConfirmPayment(ctx context.Context, callback PaymentCallback) (*model.Transaction, error)
+	SyncTransactionStatus(ctx context.Context, invoice string) error
+	GetTransactionsDueForSync(limit int) ([]model.Transaction, error)
+	ReconcileTransaction(ctx context.Context, transaction *model.Transaction) error
+	ApplyVIPStatus(status VIPStatusResponse, payload string) (*model.Transaction, error)
+	ExpireOverduePayments(limit int) (int, error)
+	// ResumePaidOrders places the orders of paid transactions whose
+	// fulfillment never started
+	ResumePaidOrders(ctx context.Context, limit int) (int, error)
+	// CancelTransaction cancels a transaction that is still awaiting payment
+	CancelTransaction(id uint, change StatusChange) (*model.Transaction, error)
+	// ResolveReview settles an order flagged for review because its supplier
+	// outcome was unknown
+	ResolveReview(id uint, resolution ReviewResolution, change StatusChange) (*model.Transaction, error)
+}
+
+// ReviewResolution is what an admin found out about an order flagged for
+// review, usually by asking the supplier
+type ReviewResolution struct {
+	// SupplierOrderID attaches the order found at the supplier, whose status
+	// is then tracked like that of any other order
+	SupplierOrderID string
+	// State settles the order directly when there is no order to attach
+	State SupplierOrderState
 }
 
 type CheckoutRequest struct {
-	ProductID   uint   `json:"product_id" validate:"required"`
-	UserID      *uint  `json:"user_id"`
-	GameID      string `json:"game_id" validate:"required"`
-	GameServer  string `json:"game_server" validate:"required"`
-	Method      string `json:"method" validate:"required"`
+	ProductID  uint   `json:"product_id" validate:"required"`
+	UserID     *uint  `json:"user_id"`
+	GameID     string `json:"game_id" validate:"required"`
+	GameServer string `json:"game_server"`
+	Method     string `json:"method" validate:"required"`
 }
 
 type transactionService struct {
+	uow             repository.UnitOfWork
 	transactionRepo repository.TransactionRepository
 	productRepo     repository.ProductRepository
-	vipReseller     VIPResellerService
+	suppliers       SupplierRegistry
+	paymentGateway  PaymentGateway
+	pricing         PricingService
+	accounts        GameAccountValidator
+	invoices        InvoiceGenerator
 }
 
 func NewTransactionService(
+	uow repository.UnitOfWork,
 	transactionRepo repository.TransactionRepository,
 	productRepo repository.ProductRepository,
-	vipReseller VIPResellerService,
+	suppliers SupplierRegistry,
+	paymentGateway PaymentGateway,
+	pricing PricingService,
+	accounts GameAccountValidator,
+	invoices InvoiceGenerator,
 ) TransactionService {
 	return &transactionService{
+		uow:             uow,
 		transactionRepo: transactionRepo,
 		productRepo:     productRepo,
-		vipReseller:     vipReseller,
+		suppliers:       suppliers,
+		paymentGateway:  paymentGateway,
+		pricing:         pricing,
+		accounts:        accounts,
+		invoices:        invoices,
 	}
 }
 
 func (s *transactionService) CreateTransaction(transaction *model.Transaction) error {
+	return s.createTransaction(s.transactionRepo, transaction)
+}
+
+func (s *transactionService) createTransaction(repo repository.TransactionRepository, transaction *model.Transaction) error {
 	if err := transaction.Validate(); err != nil {
 		return fmt.Errorf("%w: %v", ErrInvalidTransaction, err)
 	}
 
-	// Generate unique invoice number
-	transaction.Invoice = generateInvoiceNumber()
-
 	// Set initial status
-	transaction.Status = model.StatusPending
+	transaction.Status = model.StatusAwaitingPayment
 
-	// Create transaction
-	return s.transactionRepo.Create(transaction)
+	// Invoice numbers come from a sequence, but one may still be taken, e.g.
+	// after the sequence was reset. Retry with the next number.
+	for attempt := 1; ; attempt++ {
+		invoice, err := s.invoices.Next()
+		if err != nil {
+			return err
+		}
+		transaction.Invoice = invoice
+
+		err = repo.Create(transaction)
+		if !errors.Is(err, repository.ErrInvoiceExists) || attempt == maxInvoiceAttempts {
+			return err
+		}
+	}
 }
 
 func (s *transactionService) GetTransactionByID(id uint) (*model.Transaction, error) {
@@ -70,6 +153,9 @@ func (s *transactionService) GetTransactionByID(id uint) (*model.Transaction, er
 }
 
 func (s *transactionService) GetTransactionByInvoice(invoice string) (*model.Transaction, error) {
+	if !s.invoices.Valid(invoice) {
+		return nil, ErrInvalidInvoice
+	}
 	return s.transactionRepo.FindByInvoice(invoice)
 }
 
@@ -81,11 +167,103 @@ func (s *transactionService) GetUserTransactions(userID uint) ([]model.Transacti
 	return s.transactionRepo.FindByUserID(userID)
 }
 
-func (s *transactionService) UpdateTransactionStatus(id uint, status model.TransactionStatus) error {
-	return s.transactionRepo.UpdateStatus(id, status)
+func (s *transactionService) UpdateTransactionStatus(id uint, status model.TransactionStatus, change StatusChange) error {
+	transaction, err := s.transactionRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	return s.transition(transaction, status, change)
+}
+
+func (s *transactionService) GetTransactionEvents(id uint) ([]model.TransactionEvent, error) {
+	if _, err := s.transactionRepo.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	return s.transactionRepo.FindEvents(id)
 }
 
-func (s *transactionService) ProcessCheckout(checkout CheckoutRequest) (*model.Transaction, error) {
+// transition moves the transaction to a new status if the lifecycle allows it.
+// The update is a compare-and-set on the status the transaction was loaded with.
+func (s *transactionService) transition(transaction *model.Transaction, to model.TransactionStatus, change StatusChange) error {
+	return transitionIn(s.transactionRepo, transaction, to, change)
+}
+
+// transitionIn is transition using the given repository, so that the status
+// change can take part in a unit of work
+func transitionIn(repo repository.TransactionRepository, transaction *model.Transaction, to model.TransactionStatus, change StatusChange) error {
+	if !CanTransition(transaction.Status, to) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, transaction.Status, to)
+	}
+
+	if err := repo.UpdateStatus(transaction.ID, transaction.Status, to, change.event()); err != nil {
+		return err
+	}
+
+	transaction.Status = to
+	return nil
+}
+
+// transitionOnce is transition for events that may be delivered repeatedly:
+// losing the compare-and-set to a concurrent delivery is not an error.
+func (s *transactionService) transitionOnce(transaction *model.Transaction, to model.TransactionStatus, change StatusChange) error {
+	err := s.transition(transaction, to, change)
+	if errors.Is(err, repository.ErrStatusConflict) {
+		return nil
+	}
+	return err
+}
+
+// abort moves a transaction that will never be delivered to a final status
+// and returns its reserved stock, both in one database transaction. Failed
+// orders paid from a wallet are refunded to it straight away.
+func (s *transactionService) abort(transaction *model.Transaction, to model.TransactionStatus, change StatusChange) error {
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		if err := transitionIn(repos.Transactions, transaction, to, change); err != nil {
+			return err
+		}
+		if err := releaseStock(repos, transaction); err != nil {
+			return err
+		}
+		if to != model.StatusFailed || refundMethod(transaction) != model.RefundToWallet || transaction.PaidAt == nil {
+			return nil
+		}
+
+		refund := newRefund(transaction, change.Reason, nil)
+		if err := repos.Refunds.Create(refund); err != nil {
+			return err
+		}
+		return settleRefund(repos, refund, transaction, StatusChange{
+			Source: change.Source,
+			Reason: "refunded to wallet",
+		})
+	})
+	if errors.Is(err, repository.ErrStatusConflict) {
+		return nil
+	}
+	return err
+}
+
+// releaseStock returns the unit reserved at checkout, at most once
+func releaseStock(repos repository.Repositories, transaction *model.Transaction) error {
+	if transaction.StockReleased {
+		return nil
+	}
+
+	if err := repos.Products.ReleaseStock(transaction.ProductID, 1); err != nil {
+		return err
+	}
+
+	transaction.StockReleased = true
+	return repos.Transactions.Update(transaction)
+}
+
+func (s *transactionService) ProcessCheckout(ctx context.Context, checkout CheckoutRequest) (*model.Transaction, error) {
+	if checkout.Method == PaymentMethodBalance && checkout.UserID == nil {
+		return nil, ErrLoginRequired
+	}
+
 	// Get product details
 	product, err := s.productRepo.FindByID(checkout.ProductID)
 	if err != nil {
@@ -93,97 +271,594 @@ func (s *transactionService) ProcessCheckout(checkout CheckoutRequest) (*model.T
 	}
 
 	// Check if product is available
-	if !product.IsActive || product.Stock <= 0 {
+	if !product.IsActive {
 		return nil, ErrProductUnavailable
 	}
 
-	// Create transaction
-	transaction := &model.Transaction{
-		UserID:     checkout.UserID,
-		ProductID:  checkout.ProductID,
-		Method:     checkout.Method,
-		Amount:     product.Price,
+	// Catch mistyped accounts before the customer pays for them. Products
+	// not assigned to a game yet are checked by their category.
+	game := product.Category
+	if product.Game != nil {
+		game = product.Game.Slug
+	}
+	account, err := s.accounts.Validate(ctx, GameAccount{
+		Game:       game,
 		GameID:     checkout.GameID,
 		GameServer: checkout.GameServer,
-		Status:     model.StatusPending,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Save transaction
-	if err := s.CreateTransaction(transaction); err != nil {
+	// Don't take the customer's money while the order cannot be placed
+	routes, err := s.supplierRoutes(product)
+	if err != nil {
 		return nil, err
 	}
+	if len(routes) == 0 {
+		return nil, ErrProductUnavailable
+	}
+	if !anyAvailable(routes) {
+		return nil, ErrProviderUnavailable
+	}
 
-	// Create order in VIP Reseller
-	vipOrder := VIPOrder{
-		GameID:     checkout.GameID,
-		GameServer: checkout.GameServer,
-		ProductSKU: product.SKU,
+	// Resellers pay the price of their tier
+	amount, err := s.pricing.PriceFor(product, checkout.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create transaction
+	transaction := &model.Transaction{
+		UserID:       checkout.UserID,
+		ProductID:    checkout.ProductID,
+		Method:       checkout.Method,
+		Amount:       amount,
+		GameID:       checkout.GameID,
+		GameServer:   checkout.GameServer,
+		GameNickname: account.Nickname,
+	}
+
+	if checkout.Method == PaymentMethodBalance {
+		return s.checkoutWithBalance(ctx, product, transaction)
+	}
+
+	// Reserve stock and save the transaction atomically, so two buyers can
+	// never both get the last unit
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		if err := repos.Products.ReserveStock(product.ID, 1); err != nil {
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				return ErrProductUnavailable
+			}
+			return err
+		}
+		return s.createTransaction(repos.Transactions, transaction)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	vipResponse, err := s.vipReseller.CreateOrder(vipOrder)
+	// Ask the payment provider to start collecting money. The supplier order
+	// is only placed once the provider confirms payment.
+	intent, err := s.paymentGateway.CreateIntent(PaymentIntentRequest{
+		Invoice: transaction.Invoice,
+		Amount:  transaction.Amount,
+		Method:  transaction.Method,
+	})
 	if err != nil {
-		// Update transaction status to failed if VIP Reseller order fails
-		_ = s.UpdateTransactionStatus(transaction.ID, model.StatusFailed)
-		return nil, fmt.Errorf("failed to create VIP Reseller order: %v", err)
+		_ = s.abort(transaction, model.StatusFailed, StatusChange{
+			Source: model.EventSourceCheckout,
+			Reason: fmt.Sprintf("failed to create payment: %v", err),
+		})
+		return transaction, fmt.Errorf("failed to create payment: %v", err)
 	}
 
-	// Update transaction with VIP order ID
-	transaction.VipOrderID = vipResponse.OrderID
+	transaction.PaymentProvider = intent.Provider
+	transaction.PaymentReference = intent.Reference
+	transaction.PaymentURL = intent.PaymentURL
+	transaction.VANumber = intent.VANumber
+	transaction.PaymentExpiresAt = &intent.ExpiresAt
 	if err := s.transactionRepo.Update(transaction); err != nil {
+		return transaction, err
+	}
+
+	return transaction, nil
+}
+
+// checkoutWithBalance reserves stock, saves the transaction and debits the
+// buyer's wallet in one database transaction, then places the supplier order
+// straight away. Orders the suppliers reject are refunded by abort.
+func (s *transactionService) checkoutWithBalance(ctx context.Context, product *model.Product, transaction *model.Transaction) (*model.Transaction, error) {
+	paidAt := time.Now()
+	transaction.PaymentProvider = PaymentMethodBalance
+	transaction.PaidAt = &paidAt
+
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		if err := repos.Products.ReserveStock(product.ID, 1); err != nil {
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				return ErrProductUnavailable
+			}
+			return err
+		}
+		if err := s.createTransaction(repos.Transactions, transaction); err != nil {
+			return err
+		}
+
+		wallet, err := repos.Wallets.FindOrCreate(*transaction.UserID)
+		if err != nil {
+			return err
+		}
+		err = postTransfer(repos.Wallets, ledgerTransfer{
+			Type:        model.LedgerPurchase,
+			From:        walletAccount(wallet),
+			To:          systemAccount(model.AccountSales),
+			Amount:      transaction.Amount,
+			Reference:   transaction.Invoice,
+			Description: product.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		return transitionIn(repos.Transactions, transaction, model.StatusPaid, StatusChange{
+			Source: model.EventSourceCheckout,
+			Reason: "paid from wallet balance",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.Product = *product
+	if err := s.fulfillOrder(ctx, transaction, model.EventSourceCheckout); err != nil {
+		// A rejected order has been refunded; the buyer sees that from the
+		// transaction status
+		if transaction.Status != model.StatusRefunded {
+			return transaction, err
+		}
+	}
+
+	return transaction, nil
+}
+
+func (s *transactionService) CancelTransaction(id uint, change StatusChange) (*model.Transaction, error) {
+	transaction, err := s.transactionRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Status != model.StatusAwaitingPayment {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, transaction.Status, model.StatusCancelled)
+	}
+
+	if err := s.abort(transaction, model.StatusCancelled, change); err != nil {
+		return nil, err
+	}
+
+	// The payment may have been confirmed concurrently
+	return s.transactionRepo.FindByID(id)
+}
+
+func (s *transactionService) ConfirmPayment(ctx context.Context, callback PaymentCallback) (*model.Transaction, error) {
+	transaction, err := s.transactionRepo.FindByInvoice(callback.Invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may deliver the same callback more than once. A paid
+	// transaction whose order was never placed is picked up again.
+	if transaction.Status == model.StatusPaid && callback.Status == PaymentStatusPaid {
+		if err := s.resumeOrder(ctx, transaction, model.EventSourcePayment); err != nil {
+			return nil, err
+		}
+		return transaction, nil
+	}
+	if callback.Status == PaymentStatusPaid &&
+		(transaction.Status == model.StatusExpired || transaction.Status == model.StatusCancelled) {
+		return transaction, s.recordLatePayment(transaction, callback)
+	}
+	if transaction.Status != model.StatusAwaitingPayment {
+		return transaction, nil
+	}
+
+	change := StatusChange{
+		Source:  model.EventSourcePayment,
+		Payload: callback.Raw,
+	}
+
+	switch callback.Status {
+	case PaymentStatusPaid:
+		// handled below
+	case PaymentStatusExpired:
+		return transaction, s.abort(transaction, model.StatusExpired, change)
+	default:
+		return transaction, s.abort(transaction, model.StatusFailed, change)
+	}
+
+	if !callback.Amount.Equal(transaction.Amount) {
+		return nil, ErrPaymentAmountMismatch
+	}
+
+	// The payment is recorded with the status, so a paid transaction always
+	// knows when it was paid
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		if err := transitionIn(repos.Transactions, transaction, model.StatusPaid, change); err != nil {
+			return err
+		}
+
+		paidAt := time.Now()
+		transaction.PaidAt = &paidAt
+		if callback.Reference != "" {
+			transaction.PaymentReference = callback.Reference
+		}
+		return repos.Transactions.Update(transaction)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			// A concurrent delivery of this callback already claimed it
+			return s.transactionRepo.FindByID(transaction.ID)
+		}
 		return nil, err
 	}
 
-	// Decrease product stock
-	if err := s.productRepo.UpdateStock(product.ID, -1); err != nil {
+	if err := s.fulfillOrder(ctx, transaction, model.EventSourcePayment); err != nil {
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
-func (s *transactionService) SyncTransactionStatus(invoice string) error {
-	// Get transaction
-	transaction, err := s.transactionRepo.FindByInvoice(invoice)
+// recordLatePayment records a payment the provider took after the transaction
+// was closed. Its order is not placed any more; the transaction is flagged for
+// review so that an admin refunds the payment.
+func (s *transactionService) recordLatePayment(transaction *model.Transaction, callback PaymentCallback) error {
+	if transaction.PaidAt == nil {
+		paidAt := time.Now()
+		transaction.PaidAt = &paidAt
+		if callback.Reference != "" {
+			transaction.PaymentReference = callback.Reference
+		}
+		transaction.NeedsReview = true
+		transaction.Notes = fmt.Sprintf("paid %s after the transaction was %s", callback.Amount, transaction.Status)
+		if err := s.transactionRepo.Update(transaction); err != nil {
+			return err
+		}
+	}
+	return ErrLatePayment
+}
+
+// supplierRoute is one supplier SKU a product can be ordered under
+type supplierRoute struct {
+	supplier Supplier
+	sku      string
+}
+
+// supplierRoutes returns the configured suppliers of a product in order of
+// preference. Products without supplier mappings are ordered from VIP
+// Reseller under their own SKU.
+func (s *transactionService) supplierRoutes(product *model.Product) ([]supplierRoute, error) {
+	mappings, err := s.productRepo.FindSuppliers(product.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings) == 0 {
+		mappings = []model.ProductSupplier{{Supplier: SupplierVIPReseller, SKU: product.SKU}}
+	}
+
+	routes := make([]supplierRoute, 0, len(mappings))
+	for _, mapping := range mappings {
+		supplier, err := s.suppliers.Get(mapping.Supplier)
+		if err != nil {
+			// Mappings may outlive a supplier's configuration
+			continue
+		}
+		routes = append(routes, supplierRoute{supplier: supplier, sku: mapping.SKU})
+	}
+
+	return routes, nil
+}
+
+func anyAvailable(routes []supplierRoute) bool {
+	for _, route := range routes {
+		if route.supplier.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// fulfillOrder places the supplier order for a paid transaction, falling over
+// to the next supplier while orders are definitively rejected
+func (s *transactionService) fulfillOrder(ctx context.Context, transaction *model.Transaction, source model.EventSource) error {
+	routes, err := s.supplierRoutes(&transaction.Product)
 	if err != nil {
 		return err
 	}
 
-	// Skip if transaction is already in final state
-	if transaction.IsComplete() {
+	// Claim the transaction so that only one caller places the order
+	if err := s.transition(transaction, model.StatusProcessing, StatusChange{Source: source}); err != nil {
+		return err
+	}
+
+	// The order must not be abandoned half way because the caller went away
+	ctx = context.WithoutCancel(ctx)
+
+	lastErr := ErrProviderUnavailable
+	var lastRoute supplierRoute
+	var failures []string
+	for _, route := range routes {
+		order := s.supplierOrder(transaction)
+		order.SKU = route.sku
+
+		status, err := route.supplier.CreateOrder(ctx, order)
+		if err == nil {
+			return s.recordSupplierOrder(transaction, route, status, source)
+		}
+
+		lastErr, lastRoute = err, route
+		failures = append(failures, fmt.Sprintf("%s: %v", route.supplier.Name(), err))
+		if !canFailOver(err) {
+			break
+		}
+	}
+
+	if !orderRejected(lastErr) {
+		// The order may have been placed, so it is neither failed nor tried
+		// with another supplier. It stays processing until an admin finds out.
+		return s.flagForReview(transaction, lastRoute, "supplier order outcome unknown: "+strings.Join(failures, "; "))
+	}
+
+	// Fail the transaction and release its stock if no supplier took the order
+	_ = s.abort(transaction, model.StatusFailed, StatusChange{
+		Source: source,
+		Reason: "failed to place supplier order: " + strings.Join(failures, "; "),
+	})
+	return fmt.Errorf("failed to place supplier order: %w", lastErr)
+}
+
+// resumeOrder places the order of a transaction left paid, e.g. because the
+// request that was paying for it failed before claiming it. Losing the claim
+// to a concurrent caller is not an error.
+func (s *transactionService) resumeOrder(ctx context.Context, transaction *model.Transaction, source model.EventSource) error {
+	err := s.fulfillOrder(ctx, transaction, source)
+	if errors.Is(err, repository.ErrStatusConflict) {
 		return nil
 	}
+	return err
+}
 
-	// Check status in VIP Reseller
-	if transaction.VipOrderID != "" {
-		vipStatus, err := s.vipReseller.CheckStatus(transaction.VipOrderID)
-		if err != nil {
-			return fmt.Errorf("failed to check VIP Reseller status: %v", err)
+// ResumePaidOrders places the orders of transactions that were paid more
+// than resumeOrderAfter ago but never claimed for fulfillment
+func (s *transactionService) ResumePaidOrders(ctx context.Context, limit int) (int, error) {
+	transactions, err := s.transactionRepo.FindPaidBefore(time.Now().Add(-resumeOrderAfter), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	var failures []error
+	for i := range transactions {
+		if err := s.resumeOrder(ctx, &transactions[i], model.EventSourceSystem); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", transactions[i].Invoice, err))
+			continue
 		}
+		resumed++
+	}
 
-		// Map VIP Reseller status to our status
-		var newStatus model.TransactionStatus
-		switch vipStatus.Status {
-		case "success":
-			newStatus = model.StatusSuccess
-		case "failed":
-			newStatus = model.StatusFailed
-		default:
-			newStatus = model.StatusPending
+	return resumed, errors.Join(failures...)
+}
+
+// flagForReview records the supplier an order may have been placed with and
+// marks the transaction for review
+func (s *transactionService) flagForReview(transaction *model.Transaction, route supplierRoute, reason string) error {
+	transaction.Supplier = route.supplier.Name()
+	transaction.SupplierSKU = route.sku
+	transaction.NeedsReview = true
+	transaction.Notes = reason
+	return s.transactionRepo.Update(transaction)
+}
+
+func (s *transactionService) ResolveReview(id uint, resolution ReviewResolution, change StatusChange) (*model.Transaction, error) {
+	transaction, err := s.transactionRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !transaction.NeedsReview || transaction.Status != model.StatusProcessing {
+		return nil, ErrNotUnderReview
+	}
+
+	switch {
+	case resolution.SupplierOrderID != "":
+		// The reconciler checks the attached order from now on
+		transaction.SupplierOrderID = resolution.SupplierOrderID
+		transaction.SyncAttempts = 0
+		transaction.NextSyncAt = nil
+	case resolution.State == SupplierOrderSuccess || resolution.State == SupplierOrderFailed:
+		if _, err := s.applySupplierStatus(transaction, resolution.State, change); err != nil {
+			return nil, err
 		}
+	default:
+		return nil, ErrInvalidResolution
+	}
 
-		// Update transaction status if changed
-		if transaction.Status != newStatus {
-			if err := s.UpdateTransactionStatus(transaction.ID, newStatus); err != nil {
-				return err
-			}
+	transaction.NeedsReview = false
+	if err := s.transactionRepo.Update(transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// recordSupplierOrder saves which supplier took the order. Some suppliers
+// complete orders immediately, so the returned status is applied as well.
+func (s *transactionService) recordSupplierOrder(transaction *model.Transaction, route supplierRoute, status *SupplierOrderStatus, source model.EventSource) error {
+	transaction.Supplier = route.supplier.Name()
+	transaction.SupplierSKU = route.sku
+	transaction.SupplierOrderID = status.OrderID
+	if err := s.transactionRepo.Update(transaction); err != nil {
+		return err
+	}
+
+	payload, _ := json.Marshal(status)
+	_, err := s.applySupplierStatus(transaction, status.State, StatusChange{
+		Source:  source,
+		Payload: string(payload),
+	})
+	return err
+}
+
+// supplierOrder describes the order of a transaction to its supplier
+func (s *transactionService) supplierOrder(transaction *model.Transaction) SupplierOrder {
+	return SupplierOrder{
+		GameID:     transaction.GameID,
+		GameServer: transaction.GameServer,
+		SKU:        transaction.SupplierSKU,
+		RefID:      transaction.Invoice,
+	}
+}
+
+func (s *transactionService) SyncTransactionStatus(ctx context.Context, invoice string) error {
+	// Get transaction
+	transaction, err := s.GetTransactionByInvoice(invoice)
+	if err != nil {
+		return err
+	}
+
+	// Customers refreshing the status page must not push back the
+	// reconciler's next check
+	return s.reconcile(ctx, transaction, false)
+}
+
+func (s *transactionService) GetTransactionsDueForSync(limit int) ([]model.Transaction, error) {
+	return s.transactionRepo.FindDueForSync(time.Now(), limit)
+}
+
+// ReconcileTransaction checks a processing order with its supplier and
+// applies its final status. Orders that are still pending, or whose check
+// failed, are scheduled to be checked again later.
+func (s *transactionService) ReconcileTransaction(ctx context.Context, transaction *model.Transaction) error {
+	return s.reconcile(ctx, transaction, true)
+}
+
+// reconcile checks a processing order with its supplier. Only scheduled
+// checks advance the backoff of the next one.
+func (s *transactionService) reconcile(ctx context.Context, transaction *model.Transaction, scheduled bool) error {
+	// Only orders placed with a supplier have a status to sync
+	if transaction.Status != model.StatusProcessing || transaction.SupplierOrderID == "" {
+		return nil
+	}
+
+	supplier, err := s.suppliers.Get(transaction.Supplier)
+	if err != nil {
+		if scheduled {
+			_ = s.scheduleNextSync(transaction)
 		}
+		return err
+	}
+
+	status, err := supplier.CheckStatus(ctx, transaction.SupplierOrderID, s.supplierOrder(transaction))
+	if err != nil {
+		if scheduled {
+			_ = s.scheduleNextSync(transaction)
+		}
+		return fmt.Errorf("failed to check %s order status: %v", supplier.Name(), err)
+	}
+
+	payload, _ := json.Marshal(status)
+	final, err := s.applySupplierStatus(transaction, status.State, StatusChange{
+		Source:  model.EventSourceSupplierSync,
+		Payload: string(payload),
+	})
+	if err != nil || final || !scheduled {
+		return err
 	}
 
+	return s.scheduleNextSync(transaction)
+}
+
+// ApplyVIPStatus applies an order status pushed by VIP Reseller
+func (s *transactionService) ApplyVIPStatus(status VIPStatusResponse, payload string) (*model.Transaction, error) {
+	transaction, err := s.transactionRepo.FindBySupplierOrderID(SupplierVIPReseller, status.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Notifications may repeat or arrive after the reconciler already synced
+	if transaction.Status != model.StatusProcessing {
+		return transaction, nil
+	}
+
+	_, err = s.applySupplierStatus(transaction, vipOrderState(status.Status), StatusChange{
+		Source:  model.EventSourceWebhook,
+		Payload: payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// applySupplierStatus maps a supplier order state onto the transaction and
+// reports whether it was a final state
+func (s *transactionService) applySupplierStatus(transaction *model.Transaction, state SupplierOrderState, change StatusChange) (bool, error) {
+	switch state {
+	case SupplierOrderSuccess:
+		return true, s.transitionOnce(transaction, model.StatusSuccess, change)
+	case SupplierOrderFailed:
+		// Release the stock and refund balance payments of failed orders
+		return true, s.abort(transaction, model.StatusFailed, change)
+	}
+
+	return false, nil
+}
+
+func (s *transactionService) scheduleNextSync(transaction *model.Transaction) error {
+	attempts := transaction.SyncAttempts + 1
+	nextSyncAt := time.Now().Add(syncBackoff(attempts))
+	if err := s.transactionRepo.ScheduleSync(transaction.ID, attempts, nextSyncAt); err != nil {
+		return err
+	}
+
+	transaction.SyncAttempts = attempts
+	transaction.NextSyncAt = &nextSyncAt
 	return nil
 }
 
-// Helper function to generate unique invoice number
-func generateInvoiceNumber() string {
-	timestamp := time.Now().Format("20060102150405")
-	return fmt.Sprintf("INV/%s/%d", timestamp, time.Now().UnixNano()%1000)
+// syncBackoff doubles the wait after every unsuccessful check, with up to 20%
+// jitter so that orders placed together are not all re-checked together
+func syncBackoff(attempts int) time.Duration {
+	backoff := minSyncBackoff
+	for i := 1; i < attempts && backoff < maxSyncBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxSyncBackoff {
+		backoff = maxSyncBackoff
+	}
+	return backoff + time.Duration(rand.Int63n(int64(backoff)/5+1))
+}
+
+// ExpireOverduePayments expires transactions that were not paid within the
+// payment window and releases their reserved stock
+func (s *transactionService) ExpireOverduePayments(limit int) (int, error) {
+	transactions, err := s.transactionRepo.FindOverduePayments(time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	// One transaction that cannot be expired must not hold up the others
+	expired := 0
+	var failures []error
+	for i := range transactions {
+		err := s.abort(&transactions[i], model.StatusExpired, StatusChange{
+			Source: model.EventSourceSystem,
+			Reason: "payment window elapsed",
+		})
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", transactions[i].Invoice, err))
+			continue
+		}
+		expired++
+	}
+
+	return expired, errors.Join(failures...)
 }
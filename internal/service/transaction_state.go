@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"topup-game/internal/model"
+)
+
+var ErrInvalidStatusTransition = errors.New("invalid transaction status transition")
+
+// transactionTransitions lists, for every status, the statuses a transaction
+// may move to next. Statuses without an entry are terminal.
+var transactionTransitions = map[model.TransactionStatus][]model.TransactionStatus{
+	model.StatusAwaitingPayment: {
+		model.StatusPaid,
+		model.StatusExpired,
+		model.StatusCancelled,
+		model.StatusFailed,
+	},
+	model.StatusPaid: {
+		model.StatusProcessing,
+		// Refunding a paid order that was never placed fails it first
+		model.StatusFailed,
+		model.StatusRefunded,
+	},
+	model.StatusProcessing: {
+		model.StatusSuccess,
+		model.StatusFailed,
+	},
+	model.StatusSuccess: {
+		model.StatusRefunded,
+	},
+	model.StatusFailed: {
+		model.StatusRefunded,
+	},
+	// Payments that arrive after a transaction was closed are refunded
+	model.StatusExpired: {
+		model.StatusRefunded,
+	},
+	model.StatusCancelled: {
+		model.StatusRefunded,
+	},
+}
+
+// CanTransition reports whether a transaction may move from one status to another
+func CanTransition(from, to model.TransactionStatus) bool {
+	for _, next := range transactionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusChange describes what caused a status change, for the audit trail
+type StatusChange struct {
+	Source  model.EventSource
+	ActorID *uint
+	Reason  string
+	Payload string
+}
+
+func (c StatusChange) event() *model.TransactionEvent {
+	return &model.TransactionEvent{
+		Source:  c.Source,
+		ActorID: c.ActorID,
+		Reason:  c.Reason,
+		Payload: c.Payload,
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+var (
+	ErrCannotModifySelf         = errors.New("admins cannot change the role of, suspend or delete their own account")
+	ErrInvalidRole              = errors.New("invalid role")
+	ErrSuspensionReasonRequired = errors.New("a reason is required to suspend a user")
+)
+
+// generatedPasswordBytes is the entropy of passwords generated on reset; the
+// password is twice as many hex characters
+const generatedPasswordBytes = 8
+
+// UserAdminService manages accounts on behalf of an admin, the actor of
+// every change. An actorID of 0 stands for the command line.
+type UserAdminService interface {
+	ListUsers(params repository.UserQueryParams) ([]model.User, error)
+	GetUser(id uint) (*model.User, error)
+	GetUserByEmail(email string) (*model.User, error)
+	CreateUser(actorID uint, email, password string, role model.Role) (*model.User, error)
+	ChangeRole(actorID, id uint, role model.Role) (*model.User, error)
+	// Suspend blocks the user from signing in and ends all their sessions
+	Suspend(actorID, id uint, reason string) (*model.User, error)
+	Unsuspend(actorID, id uint) (*model.User, error)
+	// ResetPassword sets a new password and ends all sessions of the user.
+	// When password is empty one is generated and returned.
+	ResetPassword(actorID, id uint, password string) (string, error)
+	// DeleteUser soft deletes the user and ends all their sessions
+	DeleteUser(actorID, id uint) error
+	GetAuditLogs(params repository.AuditLogQueryParams) ([]model.AuditLog, error)
+}
+
+type userAdminService struct {
+	uow          repository.UnitOfWork
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	userService  UserService
+}
+
+func NewUserAdminService(uow repository.UnitOfWork, userRepo repository.UserRepository, auditLogRepo repository.AuditLogRepository, userService UserService) UserAdminService {
+	return &userAdminService{
+		uow:          uow,
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+		userService:  userService,
+	}
+}
+
+func (s *userAdminService) ListUsers(params repository.UserQueryParams) ([]model.User, error) {
+	return s.userRepo.FindAll(params)
+}
+
+func (s *userAdminService) GetUser(id uint) (*model.User, error) {
+	return s.userRepo.FindByID(id)
+}
+
+func (s *userAdminService) GetUserByEmail(email string) (*model.User, error) {
+	return s.userRepo.FindByEmail(email)
+}
+
+func (s *userAdminService) CreateUser(actorID uint, email, password string, role model.Role) (*model.User, error) {
+	if !validRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:    email,
+		Password: hashedPassword,
+		Role:     role,
+		Status:   model.UserActive,
+	}
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		if err := repos.Users.Create(user); err != nil {
+			return err
+		}
+		return auditUser(repos.AuditLogs, actorID, model.AuditUserCreate, user.ID, "role: %s", role)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *userAdminService) ChangeRole(actorID, id uint, role model.Role) (*model.User, error) {
+	if !validRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+
+	return s.update(id, func(repos repository.Repositories, user *model.User) error {
+		if user.Role == role {
+			return nil
+		}
+
+		from := user.Role
+		user.Role = role
+		if err := repos.Users.Update(user); err != nil {
+			return err
+		}
+		return auditUser(repos.AuditLogs, actorID, model.AuditUserRoleChange, user.ID, "%s -> %s", from, role)
+	})
+}
+
+func (s *userAdminService) Suspend(actorID, id uint, reason string) (*model.User, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrSuspensionReasonRequired
+	}
+	if actorID == id {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.setStatus(actorID, id, model.UserSuspended, model.AuditUserSuspend, strings.TrimSpace(reason))
+	if err != nil {
+		return nil, err
+	}
+
+	return user, s.userService.LogoutAll(id)
+}
+
+func (s *userAdminService) Unsuspend(actorID, id uint) (*model.User, error) {
+	return s.setStatus(actorID, id, model.UserActive, model.AuditUserUnsuspend, "")
+}
+
+func (s *userAdminService) setStatus(actorID, id uint, status model.UserStatus, action, details string) (*model.User, error) {
+	return s.update(id, func(repos repository.Repositories, user *model.User) error {
+		if user.Status == status {
+			return nil
+		}
+
+		user.Status = status
+		if err := repos.Users.Update(user); err != nil {
+			return err
+		}
+		return auditUser(repos.AuditLogs, actorID, action, user.ID, "%s", details)
+	})
+}
+
+func (s *userAdminService) ResetPassword(actorID, id uint, password string) (string, error) {
+	generated := password == ""
+	if generated {
+		var err error
+		if password, err = GeneratePassword(); err != nil {
+			return "", err
+		}
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.update(id, func(repos repository.Repositories, user *model.User) error {
+		user.Password = hashedPassword
+		if err := repos.Users.Update(user); err != nil {
+			return err
+		}
+
+		details := "password set by admin"
+		if generated {
+			details = "password generated"
+		}
+		return auditUser(repos.AuditLogs, actorID, model.AuditUserPasswordReset, user.ID, "%s", details)
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.userService.LogoutAll(id); err != nil {
+		return "", err
+	}
+
+	if !generated {
+		return "", nil
+	}
+	return password, nil
+}
+
+func (s *userAdminService) DeleteUser(actorID, id uint) error {
+	if actorID == id {
+		return ErrCannotModifySelf
+	}
+
+	_, err := s.update(id, func(repos repository.Repositories, user *model.User) error {
+		if err := repos.Users.Delete(user.ID); err != nil {
+			return err
+		}
+		return auditUser(repos.AuditLogs, actorID, model.AuditUserDelete, user.ID, "%s", user.Email)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.userService.LogoutAll(id)
+}
+
+func (s *userAdminService) GetAuditLogs(params repository.AuditLogQueryParams) ([]model.AuditLog, error) {
+	return s.auditLogRepo.FindAll(params)
+}
+
+// update loads a user and applies change to it in a unit of work, so that
+// the change and its audit log are written together
+func (s *userAdminService) update(id uint, change func(repos repository.Repositories, user *model.User) error) (*model.User, error) {
+	var user *model.User
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		var err error
+		if user, err = repos.Users.FindByID(id); err != nil {
+			return err
+		}
+		return change(repos, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// auditUser records an action an admin took on a user. Actions taken from
+// the command line have no actor.
+func auditUser(logs repository.AuditLogRepository, actorID uint, action string, userID uint, format string, args ...interface{}) error {
+	var actor *uint
+	if actorID != 0 {
+		actor = &actorID
+	}
+	return logs.Create(&model.AuditLog{
+		ActorID:    actor,
+		Action:     action,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userID,
+		Details:    fmt.Sprintf(format, args...),
+	})
+}
+
+// GeneratePassword returns a random password of the strength used for
+// generated password resets
+func GeneratePassword() (string, error) {
+	return randomToken(generatedPasswordBytes)
+}
+
+func validRole(role model.Role) bool {
+	switch role {
+	case model.RoleGuest, model.RoleReseller, model.RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"errors"
-	"time"
 	"topup-game/internal/model"
 	"topup-game/internal/repository"
 
@@ -12,13 +11,30 @@ import (
 
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrUnauthorized      = errors.New("unauthorized access")
-	ErrInvalidToken      = errors.New("invalid token")
+	ErrUnauthorized       = errors.New("unauthorized access")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenRevoked       = errors.New("token has been revoked")
+	// ErrTokenReused means a refresh token was presented after it had been
+	// exchanged, so it may have leaked; its session is revoked
+	ErrTokenReused      = errors.New("refresh token reused")
+	ErrAccountSuspended = errors.New("account is suspended")
 )
 
 type UserService interface {
 	Register(email, password string, role model.Role) (*model.User, error)
-	Login(email, password string) (string, error)
+	// Authenticate checks a user's credentials without starting a session
+	Authenticate(email, password string) (*model.User, error)
+	// Login starts a session, returning its first access and refresh tokens
+	Login(email, password string) (*TokenPair, error)
+	// Refresh exchanges a refresh token for a new token pair of its session
+	Refresh(refreshToken string) (*TokenPair, error)
+	// Logout ends the session the access token belongs to
+	Logout(claims *Claims) error
+	// LogoutAll ends every session of a user
+	LogoutAll(userID uint) error
+	// ChangePassword replaces the password after checking the current one and
+	// ends every session, returning the tokens of a new one
+	ChangePassword(userID uint, currentPassword, newPassword string) (*TokenPair, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	GetUserByID(id uint) (*model.User, error)
 	IsAdmin(userID uint) (bool, error)
@@ -26,33 +42,40 @@ type UserService interface {
 
 type userService struct {
 	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
 	jwtSecret string
+	tokens    TokenConfig
 }
 
+// Claims are carried by access tokens. The JWT ID identifies the token and
+// SessionID the login session it was issued to; either can be denylisted.
 type Claims struct {
-	UserID uint       `json:"user_id"`
-	Role   model.Role `json:"role"`
+	UserID    uint       `json:"user_id"`
+	Role      model.Role `json:"role"`
+	SessionID string     `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func NewUserService(userRepo repository.UserRepository, jwtSecret string) UserService {
+func NewUserService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, jwtSecret string, tokens TokenConfig) UserService {
 	return &userService{
 		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
 		jwtSecret: jwtSecret,
+		tokens:    tokens,
 	}
 }
 
 func (s *userService) Register(email, password string, role model.Role) (*model.User, error) {
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
 		return nil, err
 	}
 
 	user := &model.User{
 		Email:    email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Role:     role,
+		Status:   model.UserActive,
 	}
 
 	err = s.userRepo.Create(user)
@@ -65,54 +88,83 @@ func (s *userService) Register(email, password string, role model.Role) (*model.
 	return user, nil
 }
 
-func (s *userService) Login(email, password string) (string, error) {
+func (s *userService) Login(email, password string) (*TokenPair, error) {
+	user, err := s.Authenticate(email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, "")
+}
+
+func (s *userService) Authenticate(email, password string) (*model.User, error) {
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			return "", ErrInvalidCredentials
+			return nil, ErrInvalidCredentials
 		}
-		return "", err
+		return nil, err
 	}
 
 	// Compare passwords
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
-
-	// Generate JWT token
-	claims := Claims{
-		UserID: user.ID,
-		Role:   user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	if user.Status == model.UserSuspended {
+		return nil, ErrAccountSuspended
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(s.jwtSecret))
+	return user, nil
+}
+
+func (s *userService) ChangePassword(userID uint, currentPassword, newPassword string) (*TokenPair, error) {
+	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, ErrInvalidCredentials
 	}
 
-	return signedToken, nil
+	if user.Password, err = hashPassword(newPassword); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, "")
 }
 
 func (s *userService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	// Tokens issued before sessions existed cannot be revoked
+	if !ok || !token.Valid || claims.ID == "" || claims.SessionID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	denied, err := s.tokenRepo.IsDenied(claims.ID, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, ErrTokenRevoked
 	}
 
-	return nil, ErrInvalidToken
+	return claims, nil
 }
 
 func (s *userService) GetUserByID(id uint) (*model.User, error) {
@@ -134,3 +186,11 @@ func (s *userService) IsAdmin(userID uint) (bool, error) {
 
 	return user.Role == model.RoleAdmin, nil
 }
+
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
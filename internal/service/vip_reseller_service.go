@@ -1,168 +1,352 @@
 package service
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"time"
+	"net/url"
+	"strings"
+	"topup-game/internal/model"
 )
 
+var (
+	// ErrProviderUnavailable is returned without contacting a supplier while
+	// its circuit breaker is open
+	ErrProviderUnavailable = errors.New("provider unavailable")
+
+	ErrInsufficientBalance = errors.New("insufficient supplier balance")
+	ErrInvalidGameID       = errors.New("invalid game ID")
+	ErrSKUUnavailable      = errors.New("product SKU unavailable")
+)
+
+// VIPAPIError is returned when VIP Reseller answers a request with an error
+// envelope. It unwraps to ErrInsufficientBalance, ErrInvalidGameID or
+// ErrSKUUnavailable when the message identifies one of those causes.
+type VIPAPIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	cause      error
+}
+
+func newVIPAPIError(statusCode int, status, message string) *VIPAPIError {
+	return &VIPAPIError{
+		StatusCode: statusCode,
+		Status:     status,
+		Message:    message,
+		cause:      classifyVIPError(message),
+	}
+}
+
+func (e *VIPAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("VIP Reseller returned status %q (HTTP %d)", e.Status, e.StatusCode)
+	}
+	return fmt.Sprintf("VIP Reseller error: %s", e.Message)
+}
+
+func (e *VIPAPIError) Unwrap() error {
+	return e.cause
+}
+
+// vipAccountErrors are the messages, in English or Indonesian, with which VIP
+// Reseller rejects a game account. They are matched exactly: the API has no
+// error codes, and looser matching would take errors about our own API
+// credentials, such as "Invalid User ID", for bad customer input.
+var vipAccountErrors = map[string]bool{
+	"invalid game id":         true,
+	"game id is invalid":      true,
+	"game id not found":       true,
+	"invalid target":          true,
+	"id game tidak valid":     true,
+	"id game tidak ditemukan": true,
+	"target tidak valid":      true,
+	"target tidak ditemukan":  true,
+}
+
+// classifyVIPError maps the provider's free-text messages onto the errors
+// callers can act on. Unknown messages are left unclassified, so they count
+// as provider errors rather than as problems with the order.
+func classifyVIPError(message string) error {
+	message = strings.ToLower(strings.TrimRight(strings.TrimSpace(message), ".!"))
+	switch {
+	case vipAccountErrors[message]:
+		return ErrInvalidGameID
+	case containsAny(message, "balance", "saldo"):
+		return ErrInsufficientBalance
+	case containsAny(message, "sku", "layanan", "not available", "unavailable", "tidak tersedia", "gangguan"):
+		return ErrSKUUnavailable
+	}
+	return nil
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
 type VIPResellerService interface {
-	GetGameFeatures() ([]VIPProduct, error)
-	CreateOrder(order VIPOrder) (*VIPOrderResponse, error)
-	CheckStatus(orderID string) (*VIPStatusResponse, error)
+	// GetGameFeatures returns the catalog. Items that could not be decoded
+	// have Invalid set.
+	GetGameFeatures(ctx context.Context) ([]VIPProduct, error)
+	CreateOrder(ctx context.Context, order VIPOrder) (*VIPOrderResponse, error)
+	CheckStatus(ctx context.Context, orderID string) (*VIPStatusResponse, error)
+	// CheckNickname looks up the in-game nickname of an account. Unknown
+	// accounts fail with an error wrapping ErrInvalidGameID. Lookups have a
+	// circuit breaker of their own, so failing ones never hold up orders.
+	CheckNickname(ctx context.Context, game, gameID, gameServer string) (string, error)
+	ParseWebhook(payload []byte, signature string) (*VIPStatusResponse, error)
+	// Available reports whether the provider is currently accepting orders
+	Available() bool
 }
 
 type VIPProduct struct {
-	SKU         string  `json:"sku"`
-	Name        string  `json:"name"`
-	Category    string  `json:"category"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
-	Stock       int     `json:"stock"`
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Category    string      `json:"category"`
+	Price       model.Money `json:"price"`
+	Description string      `json:"description"`
+	Stock       int         `json:"stock"`
+	// Invalid says why the product could not be decoded, e.g. a malformed
+	// price. Only SKU and Name are set then.
+	Invalid string `json:"-"`
+}
+
+// decodeVIPProduct decodes one catalog item. Items that cannot be decoded are
+// returned with Invalid set, so one bad item does not fail the whole catalog.
+func decodeVIPProduct(data json.RawMessage) VIPProduct {
+	var product VIPProduct
+	err := json.Unmarshal(data, &product)
+	if err == nil {
+		return product
+	}
+
+	var identity struct {
+		SKU  string `json:"sku"`
+		Name string `json:"name"`
+	}
+	_ = json.Unmarshal(data, &identity)
+	return VIPProduct{SKU: identity.SKU, Name: identity.Name, Invalid: err.Error()}
 }
 
 type VIPOrder struct {
 	GameID     string `json:"game_id"`
 	GameServer string `json:"game_server"`
 	ProductSKU string `json:"product_sku"`
+	// RefID is our reference for the order, used by the provider to reject duplicates
+	RefID string `json:"ref_id"`
 }
 
 type VIPOrderResponse struct {
-	OrderID     string  `json:"order_id"`
-	Status      string  `json:"status"`
-	TotalPrice  float64 `json:"total_price"`
-	CreatedAt   string  `json:"created_at"`
+	OrderID    string      `json:"order_id"`
+	Status     string      `json:"status"`
+	TotalPrice model.Money `json:"total_price"`
+	CreatedAt  string      `json:"created_at"`
 }
 
 type VIPStatusResponse struct {
-	OrderID     string `json:"order_id"`
-	Status      string `json:"status"`
-	GameID      string `json:"game_id"`
-	GameServer  string `json:"game_server"`
-	ProductSKU  string `json:"product_sku"`
-	UpdatedAt   string `json:"updated_at"`
+	OrderID    string `json:"order_id"`
+	Status     string `json:"status"`
+	GameID     string `json:"game_id"`
+	GameServer string `json:"game_server"`
+	ProductSKU string `json:"product_sku"`
+	UpdatedAt  string `json:"updated_at"`
 }
 
 type vipResellerService struct {
-	baseURL string
-	apiKey  string
-	userID  string
-	client  *http.Client
+	baseURL       string
+	apiKey        string
+	userID        string
+	webhookSecret string
+	client        *supplierClient
+	// lookups sends nickname checks, which customers trigger at will
+	lookups *supplierClient
 }
 
-func NewVIPResellerService(baseURL, apiKey, userID string) VIPResellerService {
+func NewVIPResellerService(baseURL, apiKey, userID, webhookSecret string, options ClientOptions) VIPResellerService {
 	return &vipResellerService{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		userID:  userID,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL:       baseURL,
+		apiKey:        apiKey,
+		userID:        userID,
+		webhookSecret: webhookSecret,
+		client:        newSupplierClient(options),
+		lookups:       newSupplierClient(options),
 	}
 }
 
-func (s *vipResellerService) GetGameFeatures() ([]VIPProduct, error) {
-	req, err := http.NewRequest("GET", s.baseURL+"/game-feature", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
+func (s *vipResellerService) Available() bool {
+	return s.client.Available()
+}
 
-	// Add headers
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("User-ID", s.userID)
+// execute sends an authenticated request built by newRequest through client
+func (s *vipResellerService) execute(ctx context.Context, client *supplierClient, idempotent bool, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	return client.do(ctx, idempotent, func() (*http.Request, error) {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := s.client.Do(req)
+		// Add headers
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+		req.Header.Set("User-ID", s.userID)
+		return req, nil
+	})
+}
+
+// decodeResponse reads a VIP Reseller response envelope into data. Non-200
+// responses and envelopes whose status is not "success" become errors.
+func decodeResponse(resp *http.Response, data interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return fmt.Errorf("failed to read response: %v", err)
 	}
-	defer resp.Body.Close()
+
+	var envelope struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	decodeErr := json.Unmarshal(body, &envelope)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code %d", resp.StatusCode)
+		if decodeErr == nil && envelope.Message != "" {
+			return newVIPAPIError(resp.StatusCode, envelope.Status, envelope.Message)
+		}
+		return fmt.Errorf("API returned status code %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode response: %v", decodeErr)
+	}
+	if envelope.Status != "success" {
+		return newVIPAPIError(resp.StatusCode, envelope.Status, envelope.Message)
+	}
+
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return fmt.Errorf("failed to decode response data: %v", err)
 	}
+	return nil
+}
 
-	var response struct {
-		Status   string       `json:"status"`
-		Message  string       `json:"message"`
-		Products []VIPProduct `json:"data"`
+func (s *vipResellerService) GetGameFeatures(ctx context.Context) ([]VIPProduct, error) {
+	resp, err := s.execute(ctx, s.client, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", s.baseURL+"/game-feature", nil)
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	var items []json.RawMessage
+	if err := decodeResponse(resp, &items); err != nil {
+		return nil, err
 	}
 
-	return response.Products, nil
+	products := make([]VIPProduct, 0, len(items))
+	for _, item := range items {
+		products = append(products, decodeVIPProduct(item))
+	}
+	return products, nil
 }
 
-func (s *vipResellerService) CreateOrder(order VIPOrder) (*VIPOrderResponse, error) {
+func (s *vipResellerService) CreateOrder(ctx context.Context, order VIPOrder) (*VIPOrderResponse, error) {
 	jsonData, err := json.Marshal(order)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal order data: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", s.baseURL+"/order", nil)
+	// Orders are never retried: a lost response may still have placed one
+	resp, err := s.execute(ctx, s.client, false, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/order", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	// Add headers
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("User-ID", s.userID)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code %d", resp.StatusCode)
+	var orderResponse VIPOrderResponse
+	if err := decodeResponse(resp, &orderResponse); err != nil {
+		return nil, err
 	}
+	if orderResponse.OrderID == "" {
+		return nil, errors.New("VIP Reseller response is missing the order ID")
+	}
+
+	return &orderResponse, nil
+}
 
-	var response struct {
-		Status   string           `json:"status"`
-		Message  string           `json:"message"`
-		Order    VIPOrderResponse `json:"data"`
+func (s *vipResellerService) CheckStatus(ctx context.Context, orderID string) (*VIPStatusResponse, error) {
+	resp, err := s.execute(ctx, s.client, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/status/%s", s.baseURL, orderID), nil)
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	var status VIPStatusResponse
+	if err := decodeResponse(resp, &status); err != nil {
+		return nil, err
 	}
 
-	return &response.Order, nil
+	return &status, nil
 }
 
-func (s *vipResellerService) CheckStatus(orderID string) (*VIPStatusResponse, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/status/%s", s.baseURL, orderID), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+func (s *vipResellerService) CheckNickname(ctx context.Context, game, gameID, gameServer string) (string, error) {
+	query := url.Values{}
+	query.Set("code", game)
+	query.Set("target", gameID)
+	if gameServer != "" {
+		query.Set("additional_target", gameServer)
 	}
 
-	// Add headers
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
-	req.Header.Set("User-ID", s.userID)
-
-	resp, err := s.client.Do(req)
+	resp, err := s.execute(ctx, s.lookups, true, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", s.baseURL+"/game-feature/nickname?"+query.Encode(), nil)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code %d", resp.StatusCode)
+	var account struct {
+		Nickname string `json:"nickname"`
+	}
+	if err := decodeResponse(resp, &account); err != nil {
+		return "", err
+	}
+	if account.Nickname == "" {
+		return "", fmt.Errorf("%w: no account found", ErrInvalidGameID)
 	}
 
-	var response struct {
-		Status   string            `json:"status"`
-		Message  string            `json:"message"`
-		Data     VIPStatusResponse `json:"data"`
+	return account.Nickname, nil
+}
+
+// ParseWebhook authenticates and decodes an order status notification pushed
+// by VIP Reseller. The signature is the hex HMAC-SHA256 of the raw body.
+func (s *vipResellerService) ParseWebhook(payload []byte, signature string) (*VIPStatusResponse, error) {
+	if !verifyPayloadSignature(s.webhookSecret, payload, signature) {
+		return nil, ErrInvalidSignature
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	var status VIPStatusResponse
+	if err := json.Unmarshal(payload, &status); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+	if status.OrderID == "" || status.Status == "" {
+		return nil, fmt.Errorf("%w: order_id and status are required", ErrInvalidCallback)
 	}
 
-	return &response.Data, nil
+	return &status, nil
 }
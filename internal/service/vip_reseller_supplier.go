@@ -0,0 +1,60 @@
+package service
+
+import "context"
+
+// vipResellerSupplier places orders through the VIP Reseller client
+type vipResellerSupplier struct {
+	vipReseller VIPResellerService
+}
+
+func NewVIPResellerSupplier(vipReseller VIPResellerService) Supplier {
+	return &vipResellerSupplier{vipReseller: vipReseller}
+}
+
+func (s *vipResellerSupplier) Name() string {
+	return SupplierVIPReseller
+}
+
+func (s *vipResellerSupplier) Available() bool {
+	return s.vipReseller.Available()
+}
+
+func (s *vipResellerSupplier) CreateOrder(ctx context.Context, order SupplierOrder) (*SupplierOrderStatus, error) {
+	response, err := s.vipReseller.CreateOrder(ctx, VIPOrder{
+		GameID:     order.GameID,
+		GameServer: order.GameServer,
+		ProductSKU: order.SKU,
+		RefID:      order.RefID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SupplierOrderStatus{
+		OrderID: response.OrderID,
+		State:   vipOrderState(response.Status),
+	}, nil
+}
+
+func (s *vipResellerSupplier) CheckStatus(ctx context.Context, orderID string, _ SupplierOrder) (*SupplierOrderStatus, error) {
+	status, err := s.vipReseller.CheckStatus(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SupplierOrderStatus{
+		OrderID: status.OrderID,
+		State:   vipOrderState(status.Status),
+	}, nil
+}
+
+// vipOrderState maps a VIP Reseller order status onto a supplier order state
+func vipOrderState(status string) SupplierOrderState {
+	switch status {
+	case "success":
+		return SupplierOrderSuccess
+	case "failed":
+		return SupplierOrderFailed
+	}
+	return SupplierOrderPending
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/repository"
+)
+
+// TopUpInvoicePrefix marks invoices of wallet top-ups, so that payment
+// callbacks can be told apart from those of purchases
+const TopUpInvoicePrefix = "TOPUP"
+
+var (
+	ErrInvalidTopUp      = errors.New("invalid top-up data")
+	ErrReasonRequired    = errors.New("a reason is required for balance adjustments")
+	ErrInvalidAdjustment = errors.New("adjustment amount must not be zero")
+)
+
+type WalletService interface {
+	GetWallet(userID uint) (*model.Wallet, error)
+	GetEntries(userID uint, limit, offset int) ([]model.LedgerEntry, error)
+	GetTopUps(userID uint) ([]model.WalletTopUp, error)
+	CreateTopUp(userID uint, amount model.Money, method string) (*model.WalletTopUp, error)
+	ConfirmTopUp(callback PaymentCallback) (*model.WalletTopUp, error)
+	// Adjust credits (positive amount) or debits (negative amount) a wallet
+	Adjust(userID uint, amount model.Money, actorID uint, reason string) (*model.Wallet, error)
+}
+
+type walletService struct {
+	uow            repository.UnitOfWork
+	walletRepo     repository.WalletRepository
+	paymentGateway PaymentGateway
+	invoices       InvoiceGenerator
+}
+
+// NewWalletService creates the wallet service. invoices must produce numbers
+// starting with TopUpInvoicePrefix.
+func NewWalletService(uow repository.UnitOfWork, walletRepo repository.WalletRepository, paymentGateway PaymentGateway, invoices InvoiceGenerator) WalletService {
+	return &walletService{
+		uow:            uow,
+		walletRepo:     walletRepo,
+		paymentGateway: paymentGateway,
+		invoices:       invoices,
+	}
+}
+
+// IsTopUpInvoice reports whether an invoice belongs to a wallet top-up.
+// Top-ups created before invoices were made URL safe use a slash.
+func IsTopUpInvoice(invoice string) bool {
+	return strings.HasPrefix(invoice, TopUpInvoicePrefix+invoiceSeparator) ||
+		strings.HasPrefix(invoice, TopUpInvoicePrefix+"/")
+}
+
+func (s *walletService) GetWallet(userID uint) (*model.Wallet, error) {
+	return s.walletRepo.FindOrCreate(userID)
+}
+
+func (s *walletService) GetEntries(userID uint, limit, offset int) ([]model.LedgerEntry, error) {
+	wallet, err := s.walletRepo.FindOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.walletRepo.FindEntries(wallet.ID, limit, offset)
+}
+
+func (s *walletService) GetTopUps(userID uint) ([]model.WalletTopUp, error) {
+	return s.walletRepo.FindTopUps(userID)
+}
+
+// CreateTopUp starts a deposit into the user's wallet. The balance is only
+// credited once the payment provider confirms the payment.
+func (s *walletService) CreateTopUp(userID uint, amount model.Money, method string) (*model.WalletTopUp, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidTopUp)
+	}
+	if method == "" || method == PaymentMethodBalance {
+		return nil, fmt.Errorf("%w: invalid payment method", ErrInvalidTopUp)
+	}
+
+	wallet, err := s.walletRepo.FindOrCreate(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	topUp := &model.WalletTopUp{
+		UserID:   userID,
+		WalletID: wallet.ID,
+		Amount:   amount,
+		Method:   method,
+		Status:   model.TopUpPending,
+	}
+	for attempt := 1; ; attempt++ {
+		if topUp.Invoice, err = s.invoices.Next(); err != nil {
+			return nil, err
+		}
+
+		err = s.walletRepo.CreateTopUp(topUp)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, repository.ErrInvoiceExists) || attempt == maxInvoiceAttempts {
+			return nil, err
+		}
+	}
+
+	intent, err := s.paymentGateway.CreateIntent(PaymentIntentRequest{
+		Invoice: topUp.Invoice,
+		Amount:  topUp.Amount,
+		Method:  topUp.Method,
+	})
+	if err != nil {
+		_ = s.walletRepo.UpdateTopUpStatus(topUp.ID, model.TopUpPending, model.TopUpFailed)
+		return nil, fmt.Errorf("failed to create payment: %v", err)
+	}
+
+	topUp.PaymentProvider = intent.Provider
+	topUp.PaymentReference = intent.Reference
+	topUp.PaymentURL = intent.PaymentURL
+	topUp.VANumber = intent.VANumber
+	topUp.PaymentExpiresAt = &intent.ExpiresAt
+	if err := s.walletRepo.UpdateTopUp(topUp); err != nil {
+		return nil, err
+	}
+
+	return topUp, nil
+}
+
+func (s *walletService) ConfirmTopUp(callback PaymentCallback) (*model.WalletTopUp, error) {
+	topUp, err := s.walletRepo.FindTopUpByInvoice(callback.Invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers may deliver the same callback more than once
+	if topUp.Status != model.TopUpPending {
+		return topUp, nil
+	}
+
+	to := model.TopUpFailed
+	switch callback.Status {
+	case PaymentStatusPaid:
+		to = model.TopUpPaid
+		if !callback.Amount.Equal(topUp.Amount) {
+			return nil, ErrPaymentAmountMismatch
+		}
+	case PaymentStatusExpired:
+		to = model.TopUpExpired
+	}
+
+	err = s.uow.Do(func(repos repository.Repositories) error {
+		if err := repos.Wallets.UpdateTopUpStatus(topUp.ID, model.TopUpPending, to); err != nil {
+			return err
+		}
+		if to != model.TopUpPaid {
+			return nil
+		}
+
+		paidAt := time.Now()
+		topUp.PaidAt = &paidAt
+		if callback.Reference != "" {
+			topUp.PaymentReference = callback.Reference
+		}
+		if err := repos.Wallets.UpdateTopUp(topUp); err != nil {
+			return err
+		}
+
+		wallet, err := repos.Wallets.FindOrCreate(topUp.UserID)
+		if err != nil {
+			return err
+		}
+		return postTransfer(repos.Wallets, ledgerTransfer{
+			Type:        model.LedgerTopUp,
+			From:        systemAccount(model.AccountTopUps),
+			To:          walletAccount(wallet),
+			Amount:      topUp.Amount,
+			Reference:   topUp.Invoice,
+			Description: "wallet top-up",
+		})
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			// A concurrent delivery of this callback already handled it
+			return s.walletRepo.FindTopUpByInvoice(callback.Invoice)
+		}
+		return nil, err
+	}
+
+	topUp.Status = to
+	return topUp, nil
+}
+
+func (s *walletService) Adjust(userID uint, amount model.Money, actorID uint, reason string) (*model.Wallet, error) {
+	if amount.Minor == 0 {
+		return nil, ErrInvalidAdjustment
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+
+	var wallet *model.Wallet
+	err := s.uow.Do(func(repos repository.Repositories) error {
+		var err error
+		wallet, err = repos.Wallets.FindOrCreate(userID)
+		if err != nil {
+			return err
+		}
+
+		transfer := ledgerTransfer{
+			Type:        model.LedgerAdjustment,
+			From:        systemAccount(model.AccountAdjustments),
+			To:          walletAccount(wallet),
+			Amount:      amount,
+			Description: reason,
+			ActorID:     &actorID,
+		}
+		if amount.Minor < 0 {
+			transfer.From, transfer.To = transfer.To, transfer.From
+			transfer.Amount.Minor = -amount.Minor
+		}
+		return postTransfer(repos.Wallets, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+	"topup-game/internal/service"
+)
+
+// CatalogSyncConfig holds the scheduling settings of the CatalogSyncer
+type CatalogSyncConfig struct {
+	Interval time.Duration
+}
+
+// CatalogSyncer periodically syncs the product catalog with VIP Reseller, so
+// that price changes and dropped SKUs are picked up without an admin
+type CatalogSyncer struct {
+	productService service.ProductService
+	config         CatalogSyncConfig
+}
+
+func NewCatalogSyncer(productService service.ProductService, config CatalogSyncConfig) *CatalogSyncer {
+	return &CatalogSyncer{
+		productService: productService,
+		config:         config,
+	}
+}
+
+// Run syncs on every tick until ctx is cancelled. The first sync happens one
+// interval after start, so restarts do not hit the supplier every time.
+func (s *CatalogSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.sync(ctx)
+	}
+}
+
+func (s *CatalogSyncer) sync(ctx context.Context) {
+	run, err := s.productService.SyncProductsWithVIPReseller(ctx, service.SyncOptions{})
+	if err != nil {
+		log.Printf("Catalog sync: failed: %v", err)
+		return
+	}
+
+	log.Printf("Catalog sync: run %d %s, %d created, %d updated, %d deactivated, %d reactivated, %d failed",
+		run.ID, run.Status, run.Created, run.Updated, run.Deactivated, run.Reactivated, run.Failed)
+}
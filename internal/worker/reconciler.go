@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"topup-game/internal/model"
+	"topup-game/internal/service"
+)
+
+// ReconcilerConfig holds the scheduling settings of the Reconciler
+type ReconcilerConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Concurrency int
+}
+
+// Reconciler periodically brings transactions up to date without waiting for
+// a customer to look at them: processing orders are checked with their supplier,
+// paid orders that were never placed are placed and unpaid transactions past
+// their payment window are expired
+type Reconciler struct {
+	transactionService service.TransactionService
+	config             ReconcilerConfig
+}
+
+func NewReconciler(transactionService service.TransactionService, config ReconcilerConfig) *Reconciler {
+	// Expiring unpaid transactions depends on the reconciler, so it cannot be
+	// turned off
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	return &Reconciler{
+		transactionService: transactionService,
+		config:             config,
+	}
+}
+
+// Run reconciles on every tick until ctx is cancelled. In-flight checks are
+// allowed to finish before Run returns.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	expired, err := r.transactionService.ExpireOverduePayments(r.config.BatchSize)
+	if err != nil {
+		log.Printf("Reconciler: failed to expire overdue payments: %v", err)
+	}
+	if expired > 0 {
+		log.Printf("Reconciler: expired %d unpaid transactions", expired)
+	}
+
+	resumed, err := r.transactionService.ResumePaidOrders(ctx, r.config.BatchSize)
+	if err != nil {
+		log.Printf("Reconciler: failed to resume paid orders: %v", err)
+	}
+	if resumed > 0 {
+		log.Printf("Reconciler: placed %d paid orders", resumed)
+	}
+
+	transactions, err := r.transactionService.GetTransactionsDueForSync(r.config.BatchSize)
+	if err != nil {
+		log.Printf("Reconciler: failed to load pending transactions: %v", err)
+		return
+	}
+
+	// Bound the number of concurrent supplier calls
+	slots := make(chan struct{}, r.config.Concurrency)
+	var wg sync.WaitGroup
+
+	for i := range transactions {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(transaction *model.Transaction) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := r.transactionService.ReconcileTransaction(ctx, transaction); err != nil {
+				log.Printf("Reconciler: failed to sync %s: %v", transaction.Invoice, err)
+			}
+		}(&transactions[i])
+	}
+
+	wg.Wait()
+}
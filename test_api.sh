@@ -9,6 +9,13 @@ BLUE='\033[0;34m'
 # Base URL
 BASE_URL="http://localhost:8080"
 TOKEN=""
+CALLBACK_SECRET="${PAYMENT_CALLBACK_SECRET:-test-callback-secret}"
+
+# An admin created with: go run ./cmd admin create -email <email>
+if [ -z "$ADMIN_EMAIL" ] || [ -z "$ADMIN_PASSWORD" ]; then
+    echo -e "${RED}Set ADMIN_EMAIL and ADMIN_PASSWORD to the credentials of an admin${NC}"
+    exit 1
+fi
 
 echo -e "${BLUE}Starting API Tests...${NC}\n"
 
@@ -47,21 +54,17 @@ test_endpoint() {
 # 1. Test Authentication
 echo -e "\n${BLUE}=== Authentication Tests ===${NC}"
 
-# Register Admin
-test_endpoint "POST" "/api/auth/register" \
-    '{"email":"admin@test.com","password":"admin123","role":"admin"}' \
-    "false" 201
-
-# Register Reseller
+# Register Customer
 test_endpoint "POST" "/api/auth/register" \
-    '{"email":"reseller@test.com","password":"reseller123","role":"reseller"}' \
+    '{"email":"customer@test.com","password":"customer123"}' \
     "false" 201
 
 # Login Admin
 response=$(curl -s -X POST -H "Content-Type: application/json" \
-    -d '{"email":"admin@test.com","password":"admin123"}' \
+    -d "{\"email\":\"$ADMIN_EMAIL\",\"password\":\"$ADMIN_PASSWORD\"}" \
     $BASE_URL/api/auth/login)
 TOKEN=$(echo $response | jq -r '.token')
+REFRESH_TOKEN=$(echo $response | jq -r '.refresh_token')
 
 if [ ! -z "$TOKEN" ]; then
     echo -e "${GREEN}✓ Login successful${NC}"
@@ -70,6 +73,20 @@ else
     exit 1
 fi
 
+# Create Reseller (admins only)
+test_endpoint "POST" "/api/admin/users" \
+    '{"email":"reseller@test.com","password":"reseller123","role":"reseller"}' \
+    "true" 201
+
+# List Users
+test_endpoint "GET" "/api/admin/users?role=reseller" "" "true" 200
+
+# Password Reset (the same answer for unknown addresses)
+test_endpoint "POST" "/api/auth/forgot-password" '{"email":"customer@test.com"}' "false" 200
+test_endpoint "POST" "/api/auth/forgot-password" '{"email":"nobody@test.com"}' "false" 200
+test_endpoint "POST" "/api/auth/reset-password" '{"token":"invalid","password":"customer456"}' "false" 400
+test_endpoint "GET" "/api/auth/verify-email?token=invalid" "" "false" 400
+
 # 2. Test Product Management
 echo -e "\n${BLUE}=== Product Management Tests ===${NC}"
 
@@ -97,12 +114,45 @@ test_endpoint "POST" "/api/checkout" \
     '{"product_id":1,"game_id":"12345","game_server":"1001","method":"bank_transfer"}' \
     "false" 201
 
+# Pay the transaction through the fake payment provider callback
+response=$(curl -s -X POST -H "Content-Type: application/json" \
+    -d '{"product_id":1,"game_id":"12345","game_server":"1001","method":"ewallet"}' \
+    $BASE_URL/api/checkout)
+INVOICE=$(echo $response | jq -r '.transaction.invoice')
+AMOUNT=$(echo $response | jq -r '.transaction.amount.amount')
+CALLBACK="{\"invoice\":\"$INVOICE\",\"status\":\"paid\",\"amount\":\"$AMOUNT\"}"
+SIGNATURE=$(printf '%s' "$CALLBACK" | openssl dgst -sha256 -hmac "$CALLBACK_SECRET" | sed 's/^.* //')
+
+test_endpoint "POST" "/api/payments/callback" "$CALLBACK" "false" 401
+
+echo -e "\n${BLUE}Testing POST /api/payments/callback (signed)${NC}"
+status_code=$(curl -s -o /dev/null -w "%{http_code}" -X POST -H "Content-Type: application/json" \
+    -H "X-Callback-Signature: $SIGNATURE" -d "$CALLBACK" $BASE_URL/api/payments/callback)
+if [ "$status_code" -eq 200 ]; then
+    echo -e "${GREEN}✓ Success ($status_code)${NC}"
+else
+    echo -e "${RED}✗ Failed (Expected: 200, Got: $status_code)${NC}"
+fi
+
 # Check Transaction Status
-test_endpoint "GET" "/api/transaction/INV-1" "" "false" 200
+test_endpoint "GET" "/api/transaction/$INVOICE" "" "false" 200
 
 # List Transactions (Admin)
 test_endpoint "GET" "/api/admin/transactions" "" "true" 200
 
+# Wallet
+test_endpoint "GET" "/api/user/wallet" "" "true" 200
+test_endpoint "POST" "/api/user/wallet/top-ups" '{"amount":"100000","method":"bank_transfer"}' "true" 201
+test_endpoint "POST" "/api/admin/users/1/wallet/adjustments" '{"amount":"60000","reason":"test credit"}' "true" 200
+test_endpoint "POST" "/api/checkout" \
+    '{"product_id":1,"game_id":"12345","game_server":"1001","method":"balance"}' \
+    "true" 201
+
+# Paying from balance needs a signed-in user
+test_endpoint "POST" "/api/checkout" \
+    '{"product_id":1,"game_id":"12345","game_server":"1001","method":"balance"}' \
+    "false" 401
+
 # 4. Test Error Cases
 echo -e "\n${BLUE}=== Error Cases Tests ===${NC}"
 
@@ -124,5 +174,11 @@ echo -e "\n${BLUE}=== VIP Reseller Integration Tests ===${NC}"
 
 # Sync Products
 test_endpoint "POST" "/api/admin/products/sync" "" "true" 200
+test_endpoint "POST" "/api/admin/products/sync?dry_run=true" "" "true" 200
+test_endpoint "GET" "/api/admin/products/sync/runs" "" "true" 200
+
+# Reused Refresh Token (revokes the admin session, so it runs last)
+test_endpoint "POST" "/api/auth/refresh" "{\"refresh_token\":\"$REFRESH_TOKEN\"}" "false" 200
+test_endpoint "POST" "/api/auth/refresh" "{\"refresh_token\":\"$REFRESH_TOKEN\"}" "false" 401
 
 echo -e "\n${BLUE}Testing Complete!${NC}"
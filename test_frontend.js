@@ -80,7 +80,7 @@ const puppeteer = require('puppeteer');
         // Test Transaction Management
         await page.click('#transactions-tab');
         await page.waitForSelector('.transaction-list');
-        await page.select('#statusFilter', 'pending');
+        await page.select('#statusFilter', 'awaiting_payment');
         await page.waitForSelector('.transaction-item');
         console.log('✓ Transaction management tested');
 